@@ -0,0 +1,7 @@
+package mysql
+
+// Binary escapes as _binary'...' string literal, for BLOB and VARBINARY columns
+type Binary []byte
+
+// Hex escapes as X'...' hex literal, same as plain []byte
+type Hex []byte
@@ -0,0 +1,263 @@
+package mysql
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+)
+
+// DefaultDumpBatchSize rows per INSERT statement of Dump
+const DefaultDumpBatchSize = 1000
+
+// DumpOptions configures Dump
+type DumpOptions struct {
+	// Tables to dump in order, every base table and view if empty.
+	// Views are written after the tables
+	Tables []string
+	// Where filters rows of a table: {"users": "deleted_at IS NULL"}
+	Where map[string]string
+	// BatchSize rows per INSERT statement, DefaultDumpBatchSize if <= 0
+	BatchSize int
+	// NoData writes table definitions only
+	NoData bool
+	// Gzip compresses the output
+	Gzip bool
+}
+
+// dumpColumn column of a dumped table
+type dumpColumn struct {
+	binary bool // written as hex literal
+	number bool // written unquoted
+}
+
+// Dump writes CREATE TABLE and batched INSERT statements of tables, then
+// CREATE VIEW statements of views, to w. Tables, columns and rows are read in one consistent snapshot on a
+// dedicated connection, in time zone UTC. Generated columns are skipped,
+// invisible ones are included, binary values are written as hex literals.
+// The dump sets the time zone and the sql_mode its literals are written for,
+// NO_BACKSLASH_ESCAPES included when the server uses it, and restores both
+func (db *DB) Dump(ctx context.Context, w io.Writer, opts *DumpOptions) (err error) {
+	if opts == nil {
+		opts = &DumpOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultDumpBatchSize
+	}
+
+	conn, err := db.Conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// the session settings must not reach the pool
+	defer conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	for _, statement := range []string{
+		"SET SESSION time_zone = '+00:00'",
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT",
+	} {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	defer conn.ExecContext(context.Background(), "ROLLBACK")
+	snapshot := db.WithConn(conn).WithContext(ctx)
+
+	all, err := snapshot.Tables()
+	if err != nil {
+		return err
+	}
+	isView := make(map[string]bool)
+	names := opts.Tables
+	for _, table := range all {
+		isView[table.Name] = table.Type == "VIEW"
+		if len(opts.Tables) == 0 && (table.Type == "BASE TABLE" || table.Type == "VIEW") {
+			names = append(names, table.Name)
+		}
+	}
+	// views may select from any table, they are created last
+	var tables, views []string
+	for _, name := range names {
+		if isView[name] {
+			views = append(views, name)
+		} else {
+			tables = append(tables, name)
+		}
+	}
+
+	if opts.Gzip {
+		zw := gzip.NewWriter(w)
+		defer func() {
+			if closeErr := zw.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = zw
+	}
+	bw := bufio.NewWriterSize(w, 64<<10)
+	defer func() {
+		if flushErr := bw.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	sqlMode := "NO_AUTO_VALUE_ON_ZERO"
+	if db.esc.noBackslashEscapes {
+		sqlMode += ",NO_BACKSLASH_ESCAPES"
+	}
+	bw.WriteString("-- mysql-go dump\n\nSET NAMES utf8mb4;\n" +
+		"SET @OLD_TIME_ZONE=@@TIME_ZONE;\nSET TIME_ZONE='+00:00';\n" +
+		"SET @OLD_SQL_MODE=@@SQL_MODE;\nSET SQL_MODE='" + sqlMode + "';\n" +
+		"SET FOREIGN_KEY_CHECKS=0;\nSET UNIQUE_CHECKS=0;\n")
+	for _, table := range tables {
+		if err := snapshot.dumpTable(ctx, conn, bw, table, opts.Where[table], batchSize, opts.NoData); err != nil {
+			return err
+		}
+	}
+	for _, view := range views {
+		if err := snapshot.dumpView(ctx, conn, bw, view); err != nil {
+			return err
+		}
+	}
+	_, err = bw.WriteString("\nSET UNIQUE_CHECKS=1;\nSET FOREIGN_KEY_CHECKS=1;\nSET SQL_MODE=@OLD_SQL_MODE;\nSET TIME_ZONE=@OLD_TIME_ZONE;\n")
+	return err
+}
+
+// dumpView writes definition of view, db is bound to conn
+func (db *DB) dumpView(ctx context.Context, conn *sql.Conn, w *bufio.Writer, view string) error {
+	var name, create, charset, collation string
+	if err := conn.QueryRowContext(ctx, "SHOW CREATE VIEW "+db.qualified(view)).Scan(&name, &create, &charset, &collation); err != nil {
+		return err
+	}
+	escapedView := EscapeID(view, true)
+	w.WriteString("\n-- View " + escapedView + "\n\nDROP VIEW IF EXISTS " + escapedView + ";\n")
+	w.WriteString(create)
+	_, err := w.WriteString(";\n")
+	return err
+}
+
+// dumpTable writes definition and rows of table, db is bound to conn
+func (db *DB) dumpTable(ctx context.Context, conn *sql.Conn, w *bufio.Writer, table, where string, batchSize int, noData bool) error {
+	var name, create string
+	if err := conn.QueryRowContext(ctx, "SHOW CREATE TABLE "+db.qualified(table)).Scan(&name, &create); err != nil {
+		return err
+	}
+	escapedTable := EscapeID(table, true)
+	w.WriteString("\n-- Table " + escapedTable + "\n\nDROP TABLE IF EXISTS " + escapedTable + ";\n")
+	w.WriteString(create)
+	w.WriteString(";\n")
+	if noData {
+		return nil
+	}
+
+	tableColumns, err := db.Columns(table)
+	if err != nil {
+		return err
+	}
+	var columns []dumpColumn
+	var names []string
+	for _, column := range tableColumns {
+		if column.Generated != "" || strings.Contains(column.Extra, "GENERATED") && !strings.Contains(column.Extra, "DEFAULT_GENERATED") {
+			continue
+		}
+		columns = append(columns, dumpColumn{
+			binary: isBinaryType(column.DataType),
+			number: isNumberType(column.DataType),
+		})
+		names = append(names, column.Name)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	escapedColumns := EscapeIDs(names, true)
+
+	query := "SELECT " + escapedColumns + " FROM " + db.qualified(table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]sql.RawBytes, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	insert := "INSERT INTO " + escapedTable + " (" + escapedColumns + ") VALUES\n"
+	var buf []byte
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		if n == 0 {
+			buf = append(buf[:0], insert...)
+		} else {
+			buf = append(buf, ",\n"...)
+		}
+		buf = db.appendDumpRow(buf, columns, values)
+		if n++; n == batchSize {
+			buf = append(buf, ";\n"...)
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			n = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if n > 0 {
+		buf = append(buf, ";\n"...)
+		_, err = w.Write(buf)
+	}
+	return err
+}
+
+// appendDumpRow appends (values) of a row to buf
+func (db *DB) appendDumpRow(buf []byte, columns []dumpColumn, values []sql.RawBytes) []byte {
+	buf = append(buf, '(')
+	for i, value := range values {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		switch {
+		case value == nil:
+			buf = append(buf, "NULL"...)
+		case columns[i].binary:
+			buf, _ = db.esc.appendValue(buf, []byte(value), false)
+		case columns[i].number:
+			buf = append(buf, value...)
+		default:
+			buf = db.esc.appendBytes(buf, value)
+		}
+	}
+	return append(buf, ')')
+}
+
+// isBinaryType reports whether values of data type are written as hex
+func isBinaryType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		return true
+	}
+	return false
+}
+
+// isNumberType reports whether values of data type are written unquoted
+func isNumberType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "numeric", "float", "double", "real":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	db, d := newTestDB(t,
+		result("information_schema.TABLES", []string{"name", "type", "engine", "collation", "auto_increment", "comment"},
+			[]driver.Value{"users", "BASE TABLE", "InnoDB", "", nil, ""},
+			[]driver.Value{"v", "VIEW", "", "", nil, "VIEW"},
+		),
+		result("SHOW CREATE TABLE", []string{"Table", "Create Table"}, []driver.Value{"users", "CREATE TABLE `users` (...)"}),
+		result("SHOW CREATE VIEW", []string{"View", "Create View", "character_set_client", "collation_connection"},
+			[]driver.Value{"v", "CREATE VIEW `v` AS select `id` from `users`", "utf8mb4", "utf8mb4_0900_ai_ci"},
+		),
+		result("information_schema.COLUMNS", []string{"name", "data_type", "extra", "generated"},
+			[]driver.Value{"id", "int", "auto_increment", ""},
+			[]driver.Value{"name", "varchar", "", ""},
+			[]driver.Value{"avatar", "blob", "", ""},
+			[]driver.Value{"upper_name", "varchar", "VIRTUAL GENERATED", "upper(name)"},
+			[]driver.Value{"secret", "varchar", "INVISIBLE", ""},
+			[]driver.Value{"created", "datetime", "DEFAULT_GENERATED", ""},
+		),
+		result("FROM `app`.`users`", []string{"id", "name", "avatar", "secret", "created"},
+			[]driver.Value{int64(1), "it's", []byte{0, 1}, nil, "2024-01-02 03:04:05"},
+			[]driver.Value{int64(2), "b", nil, "s", "2024-01-02 03:04:06"},
+			[]driver.Value{int64(3), "c", []byte{}, "t", "2024-01-02 03:04:07"},
+		),
+	)
+
+	var out bytes.Buffer
+	if err := db.Dump(context.Background(), &out, &DumpOptions{Where: map[string]string{"users": "id > 0"}, BatchSize: 2, Gzip: true}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "INSERT INTO `users` (`id`, `name`, `avatar`, `secret`, `created`) VALUES\n" +
+		"(1,'it\\'s',X'0001',NULL,'2024-01-02 03:04:05'),\n" +
+		"(2,'b',NULL,'s','2024-01-02 03:04:06');\n" +
+		"INSERT INTO `users` (`id`, `name`, `avatar`, `secret`, `created`) VALUES\n" +
+		"(3,'c',X'','t','2024-01-02 03:04:07');\n"
+	header := "SET TIME_ZONE='+00:00';\nSET @OLD_SQL_MODE=@@SQL_MODE;\nSET SQL_MODE='NO_AUTO_VALUE_ON_ZERO';\nSET FOREIGN_KEY_CHECKS=0;\n"
+	for _, part := range []string{header, "SET SQL_MODE=@OLD_SQL_MODE;\nSET TIME_ZONE=@OLD_TIME_ZONE;\n", "DROP TABLE IF EXISTS `users`;\nCREATE TABLE `users` (...);\n", want, "SET FOREIGN_KEY_CHECKS=1;\n"} {
+		if !strings.Contains(string(dump), part) {
+			t.Errorf("dump misses %q:\n%s", part, dump)
+		}
+	}
+	view := "DROP VIEW IF EXISTS `v`;\nCREATE VIEW `v` AS select `id` from `users`;\n"
+	if i := strings.Index(string(dump), view); i < strings.Index(string(dump), want) {
+		t.Errorf("view missing or before the tables:\n%s", dump)
+	}
+	wantExecs := []string{"SET SESSION time_zone = '+00:00'", "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION WITH CONSISTENT SNAPSHOT", "ROLLBACK"}
+	if got := d.executed(); !reflect.DeepEqual(got, wantExecs) {
+		t.Errorf("executed %q, want %q", got, wantExecs)
+	}
+	if n := db.Conn.Stats().OpenConnections; n != 0 {
+		// the snapshot connection is discarded, others read outside the snapshot
+		t.Errorf("%d connections left open, want 0", n)
+	}
+
+	db.esc.noBackslashEscapes = true
+	out.Reset()
+	if err := db.Dump(context.Background(), &out, &DumpOptions{Tables: []string{"users"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{"SET SQL_MODE='NO_AUTO_VALUE_ON_ZERO,NO_BACKSLASH_ESCAPES';\n", "(1,'it''s',"} {
+		if !strings.Contains(out.String(), part) {
+			t.Errorf("dump misses %q:\n%s", part, out.String())
+		}
+	}
+
+	// listed views are created after the tables too, never read as tables
+	out.Reset()
+	if err := db.Dump(context.Background(), &out, &DumpOptions{Tables: []string{"v", "users"}, NoData: true}); err != nil {
+		t.Fatal(err)
+	}
+	if i := strings.Index(out.String(), "CREATE VIEW"); i < strings.Index(out.String(), "CREATE TABLE") {
+		t.Errorf("view missing or before the tables:\n%s", out.String())
+	}
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscaperNoBackslashEscapes(t *testing.T) {
+	tests := []struct {
+		val       interface{}
+		backslash string
+		quotes    string
+	}{
+		{`it's`, `'it\'s'`, `'it''s'`},
+		{`a\'b`, `'a\\\'b'`, `'a\''b'`},
+		{[]string{`x'`, "y\n"}, `'x\'', 'y\n'`, "'x''', 'y\n'"},
+	}
+	for _, test := range tests {
+		got, err := escaper{}.escape(test.val, false)
+		if err != nil || got != test.backslash {
+			t.Errorf("backslash escape(%q) = %s %v, want %s", test.val, got, err, test.backslash)
+		}
+		got, err = escaper{noBackslashEscapes: true}.escape(test.val, false)
+		if err != nil || got != test.quotes {
+			t.Errorf("quotes escape(%q) = %s %v, want %s", test.val, got, err, test.quotes)
+		}
+	}
+
+	if !hasSQLMode("STRICT_TRANS_TABLES,no_backslash_escapes", "NO_BACKSLASH_ESCAPES") {
+		t.Error("hasSQLMode did not find NO_BACKSLASH_ESCAPES")
+	}
+	if hasSQLMode("STRICT_TRANS_TABLES", "NO_BACKSLASH_ESCAPES") {
+		t.Error("hasSQLMode found NO_BACKSLASH_ESCAPES")
+	}
+}
+
+func TestLikeNoBackslashEscapes(t *testing.T) {
+	cond := Contains("name", `50%_\`)
+	for _, esc := range []escaper{{}, {noBackslashEscapes: true}} {
+		arg, err := esc.escape(cond.Args[0], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the pattern is %50!%!_\% in both modes, ! escapes the wildcards
+		got := strings.Replace(cond.SQL, "?", arg, 1)
+		want := "`name` LIKE '%50!%!_" + `\\` + "%' ESCAPE '!'"
+		if esc.noBackslashEscapes {
+			want = "`name` LIKE '%50!%!_" + `\` + "%' ESCAPE '!'"
+		}
+		if got != want {
+			t.Errorf("noBackslashEscapes=%t: %s, want %s", esc.noBackslashEscapes, got, want)
+		}
+	}
+}
@@ -1,55 +1,20 @@
 package mysql_test
 
 import (
-	"log"
+	"reflect"
+	"strconv"
 	"testing"
 
-	MySQL "github.com/go-sql-driver/mysql"
 	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/mysqltest/mysqlserver"
 )
 
-func genConfig() *MySQL.Config {
-	return mysql.NewConfig(&mysql.Config{
-		User:       "root",
-		Passwd:     "12345678",
-		DBName:     "test",
-		Host:       "localhost",
-		UnixSocket: "",
-		Port:       3306,
-	})
-}
-
-func genConfig2() *MySQL.Config {
-	mysqlConfig := MySQL.NewConfig()
-	mysqlConfig.Collation = "utf8mb4_unicode_ci"
-	mysqlConfig.MultiStatements = true
-	mysqlConfig.Params = map[string]string{
-		"charset": "utf8mb4,utf8",
-	}
-	mysqlConfig.User = "root"
-	mysqlConfig.Passwd = "12345678"
-	mysqlConfig.Net = "tcp"
-	mysqlConfig.Addr = "localhost:3306"
-	mysqlConfig.DBName = "test"
-	return mysqlConfig
-}
-
-func TestMain(t *testing.T) {
-	// mysql'll auto reconnect when lost the connection
-	db, err := mysql.New(genConfig())
-	if err != nil {
-		t.Fatal(err)
-	}
-	log.Println("DB connected")
-	defer db.Conn.Close()
+func TestHelpers(t *testing.T) {
+	// in-memory server, stopped at the end of the test
+	db := mysqlserver.NewServer(t)
 
 	// Query
-	log.Println(" >> Query:")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	_, err = db.Query(`DROP TABLE IF EXISTS ` + mysql.EscapeID("users", false))
+	_, err := db.Query(`DROP TABLE IF EXISTS ` + mysql.EscapeID("users", false))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,66 +27,54 @@ func TestMain(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("Query OK")
 
 	// Single
-	log.Println(" >> Single:")
 	single, err := db.Single("select ?", 1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if single.Valid {
-		log.Println(single.String)
-	} else {
-		log.Println(nil)
+	if !single.Valid || single.String != "1" {
+		t.Errorf("Single = %v, want 1", single)
 	}
 
 	// Rows of Sets
-	log.Println(" >> Rows of Sets:")
-	resultSets, err := db.SetRowsNil(`select null; select 1; select 2`) // enable MultiStatements to query this
+	resultSets, err := db.SetRowsNil(`select null as n; select 1; select 2`) // enable MultiStatements to query this
 	if err != nil {
 		t.Fatal(err)
 	}
-	for si, rows := range resultSets {
-		// It's OK to range over nil setRows (slices)
-		for ri, row := range rows {
-			log.Printf("Row %d in set %d:", ri, si)
-			for key, val := range row {
-				log.Println(key+":", val)
-			}
-		}
+	if len(resultSets) != 3 {
+		t.Fatalf("SetRowsNil sets = %d, want 3", len(resultSets))
+	}
+	if len(resultSets[0]) != 1 || resultSets[0][0]["n"] == nil || resultSets[0][0]["n"].Valid {
+		t.Errorf("set 0 = %v, want one NULL row", resultSets[0])
+	}
+	if len(resultSets[2]) != 1 || resultSets[2][0]["2"] == nil || resultSets[2][0]["2"].String != "2" {
+		t.Errorf("set 2 = %v, want one row of 2", resultSets[2])
 	}
 
 	// Row
-	log.Println(" >> Row:")
-	row, err := db.Row(`select null`)
+	row, err := db.Row(`select null as n`)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if row == nil {
-		// No row found
-		log.Println("No row found")
-	} else {
-		for key, val := range row {
-			log.Println(key+":", val)
-		}
+	if row == nil || row["n"] == nil || row["n"].Valid {
+		t.Errorf("Row = %v, want NULL column", row)
+	}
+	row, err = db.Row(`select * from users`)
+	if err != nil || row != nil {
+		t.Errorf("Row of empty table = %v, %v, want nil", row, err)
 	}
 
 	// Rows
-	log.Println(" >> Rows:")
 	rows, err := db.Rows(`select 1 union select 2`)
 	if err != nil {
 		t.Fatal(err)
 	}
-	for i, row := range rows {
-		log.Println("Row", i)
-		for key, val := range row {
-			log.Println(key+":", val)
-		}
+	if len(rows) != 2 || rows[0]["1"].String != "1" || rows[1]["1"].String != "2" {
+		t.Errorf("Rows = %v, want 1 and 2", rows)
 	}
 
 	// Insert
-	log.Println(" >> Insert:")
 	insertID, err := db.Insert("users", []string{"name", "data"}, []interface{}{
 		[]string{"Vịnh", "123456"},
 		[]string{"Vịnh 2", "1234567"},
@@ -129,10 +82,16 @@ func TestMain(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("InsertID:", insertID)
+	// id of the first inserted row
+	firstID, err := db.Single("select id from users where name = ?", "Vịnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !firstID.Valid || firstID.String != strconv.FormatInt(insertID, 10) {
+		t.Errorf("InsertID = %d, want id %v of the first row", insertID, firstID.String)
+	}
 
 	// Insert or Update
-	log.Println(" >> InsertUpdate:")
 	_, err = db.InsertUpdate("users", []string{"id", "name", "data"}, []interface{}{
 		[]string{"1", "Vinh", "234567"},
 		[]string{"3", "Vinh 3", "12345678"},
@@ -141,27 +100,63 @@ func TestMain(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("InsertUpdate done")
 
 	// Update
-	log.Println(" >> Update:")
 	updatedRows, err := db.Update("users", map[string]string{
 		"name": "Vinh 3 Updated",
 		"data": "11111111",
-	}, struct{ ID int }{ID: 3}, 1) // Field in struct must be exported
+	}, struct {
+		ID int `db:"id"`
+	}{ID: 3}, 1) // Field in struct must be exported, column name from db tag
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedRows != 1 {
+		t.Errorf("Updated rows = %d, want 1", updatedRows)
+	}
+
+	// Transaction
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Update("users", map[string]string{"data": "tx"}, map[string]int{"id": 1})
+	if err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+	err = tx.Commit()
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("Updated rows:", updatedRows)
 
 	// Delete
-	log.Println(" >> Delete:")
 	deletedRows, err := db.Delete("users", map[string]int{"id": 4}, 10)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("DeletedRows rows:", deletedRows)
+	if deletedRows != 1 {
+		t.Errorf("Deleted rows = %d, want 1", deletedRows)
+	}
 
-	log.Println("Test: OK")
-	t.Fail()
+	// Scan into structs
+	type user struct {
+		ID   int     `db:"id"`
+		Name string  `db:"name"`
+		Data *string `db:"data"`
+	}
+	var users []user
+	err = db.ScanRows(&users, "select * from users order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := func(s string) *string { return &s }
+	want := []user{
+		{ID: 1, Name: "Vinh", Data: data("tx")},
+		{ID: 2, Name: "Vịnh 2", Data: data("1234567")},
+		{ID: 3, Name: "Vinh 3 Updated", Data: data("11111111")},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("users = %+v, want %+v", users, want)
+	}
 }
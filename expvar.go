@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"database/sql"
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets upper bounds in seconds of latency histograms
+var LatencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// expvarSink publishes metrics as expvar variables
+type expvarSink struct {
+	pool    *expvar.Map
+	queries *expvar.Map
+	mu      sync.Mutex // creating per op maps
+}
+
+// NewExpvarSink returns a MetricsSink publishing expvar map name with
+// "pool" statistics and "queries" per op: count, errors, seconds and
+// cumulative latency buckets "le_<seconds>".
+// A map name already published is reused, the new sink resets its "pool" and
+// "queries"; it panics if name is published as another kind of variable
+func NewExpvarSink(name string) MetricsSink {
+	var root *expvar.Map
+	if v := expvar.Get(name); v != nil {
+		m, ok := v.(*expvar.Map)
+		if !ok {
+			panic("mysql: expvar " + name + " is not a map")
+		}
+		root = m
+	} else {
+		root = expvar.NewMap(name)
+	}
+	s := &expvarSink{
+		pool:    new(expvar.Map).Init(),
+		queries: new(expvar.Map).Init(),
+	}
+	root.Set("pool", s.pool)
+	root.Set("queries", s.queries)
+	return s
+}
+
+func (s *expvarSink) SetPoolStats(stats sql.DBStats) {
+	set := func(key string, value int64) {
+		v := new(expvar.Int)
+		v.Set(value)
+		s.pool.Set(key, v)
+	}
+	set("max_open", int64(stats.MaxOpenConnections))
+	set("open", int64(stats.OpenConnections))
+	set("in_use", int64(stats.InUse))
+	set("idle", int64(stats.Idle))
+	set("wait_count", stats.WaitCount)
+	set("wait_duration_ns", int64(stats.WaitDuration))
+	set("max_idle_closed", stats.MaxIdleClosed)
+	set("max_lifetime_closed", stats.MaxLifetimeClosed)
+}
+
+func (s *expvarSink) ObserveQuery(op Op, table string, duration time.Duration, err error) {
+	m, ok := s.queries.Get(string(op)).(*expvar.Map)
+	if !ok {
+		s.mu.Lock()
+		if m, ok = s.queries.Get(string(op)).(*expvar.Map); !ok {
+			m = new(expvar.Map).Init()
+			s.queries.Set(string(op), m)
+		}
+		s.mu.Unlock()
+	}
+	m.Add("count", 1)
+	if err != nil {
+		m.Add("errors", 1)
+	}
+	seconds := duration.Seconds()
+	m.AddFloat("seconds", seconds)
+	for _, bucket := range LatencyBuckets {
+		if seconds <= bucket {
+			m.Add("le_"+strconv.FormatFloat(bucket, 'g', -1, 64), 1)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// tokenKind kind of sql token
+type tokenKind int
+
+const (
+	tokenWord        tokenKind = iota // keyword, function or unquoted identifier
+	tokenIdentifier                   // `quoted` identifier
+	tokenVariable                     // @user or @@system variable
+	tokenString                       // 'string', "string", _charset'string'
+	tokenNumber                       // 1, -1.5e3
+	tokenHex                          // X'ff', 0xff, B'01', 0b01
+	tokenPlaceholder                  // ?
+	tokenOperator                     // punctuation and operators
+)
+
+// token sql token, pos is its byte offset
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits sqlQuery into tokens, comments and whitespace are dropped.
+// Strings follow escapeString rules (backslash escapes and doubled quotes),
+// identifiers follow EscapeID rules (doubled backticks)
+func tokenize(sqlQuery string) []token {
+	var tokens []token
+	n := len(sqlQuery)
+	for i := 0; i < n; {
+		c := sqlQuery[i]
+		start := i
+		switch {
+		case isSpace(c):
+			i++
+			continue
+		case c == '#' || c == '-' && i+2 < n && sqlQuery[i+1] == '-' && isSpace(sqlQuery[i+2]):
+			for i < n && sqlQuery[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < n && sqlQuery[i+1] == '*':
+			end := strings.Index(sqlQuery[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+			continue
+		case c == '\'' || c == '"':
+			i = skipQuoted(sqlQuery, i, c, true)
+			tokens = append(tokens, token{tokenString, sqlQuery[start:i], start})
+		case c == '`':
+			i = skipQuoted(sqlQuery, i, c, false)
+			tokens = append(tokens, token{tokenIdentifier, sqlQuery[start:i], start})
+		case c == '?':
+			i++
+			tokens = append(tokens, token{tokenPlaceholder, "?", start})
+		case (c == 'x' || c == 'X' || c == 'b' || c == 'B') && i+1 < n && sqlQuery[i+1] == '\'':
+			i = skipQuoted(sqlQuery, i+1, '\'', false)
+			tokens = append(tokens, token{tokenHex, sqlQuery[start:i], start})
+		case c == '0' && i+2 < n && (sqlQuery[i+1] == 'x' || sqlQuery[i+1] == 'b') && isWordChar(sqlQuery[i+2]):
+			i = skipWord(sqlQuery, i)
+			tokens = append(tokens, token{tokenHex, sqlQuery[start:i], start})
+		case isDigit(c) || c == '.' && i+1 < n && isDigit(sqlQuery[i+1]):
+			i = skipNumber(sqlQuery, i)
+			if i < n && isWordChar(sqlQuery[i]) {
+				// identifiers may start with digits
+				i = skipWord(sqlQuery, i)
+				tokens = append(tokens, token{tokenWord, sqlQuery[start:i], start})
+				break
+			}
+			tokens = append(tokens, token{tokenNumber, sqlQuery[start:i], start})
+		case c == '@':
+			i++
+			for i < n && (sqlQuery[i] == '@' || sqlQuery[i] == '.' || isWordChar(sqlQuery[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenVariable, sqlQuery[start:i], start})
+		case isWordChar(c):
+			i = skipWord(sqlQuery, i)
+			if c == '_' && i < n && sqlQuery[i] == '\'' {
+				// _charset'string' introducer
+				i = skipQuoted(sqlQuery, i, '\'', true)
+				tokens = append(tokens, token{tokenString, sqlQuery[start:i], start})
+				break
+			}
+			tokens = append(tokens, token{tokenWord, sqlQuery[start:i], start})
+		default:
+			i += operatorLength(sqlQuery[i:])
+			tokens = append(tokens, token{tokenOperator, sqlQuery[start:i], start})
+		}
+	}
+	return tokens
+}
+
+// skipQuoted returns offset after quoted text starting at i, a doubled quote
+// stays inside, backslash escapes the next byte if backslash is set
+func skipQuoted(s string, i int, quote byte, backslash bool) int {
+	for i++; i < len(s); i++ {
+		if s[i] == '\\' && backslash {
+			i++
+		} else if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func skipWord(s string, i int) int {
+	for i < len(s) && isWordChar(s[i]) {
+		i++
+	}
+	return i
+}
+
+func skipNumber(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+	if i+1 < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if s[j] == '-' || s[j] == '+' {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			i = j
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+var operators = []string{"<=>", "->>", "<=", ">=", "<>", "!=", ":=", "||", "&&", "<<", ">>", "->"}
+
+func operatorLength(s string) int {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return len(op)
+		}
+	}
+	return 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isWordChar unquoted identifier characters, bytes of multibyte characters included
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '$' || c >= 0x80
+}
+
+// Normalize returns digest text of sqlQuery like performance_schema: comments
+// are stripped, literals and placeholders become ?, IN lists and VALUES rows
+// collapse to (...), keywords and functions are upper case, identifiers are
+// quoted and tokens are separated by single spaces
+func Normalize(sqlQuery string) string {
+	tokens := tokenize(sqlQuery)
+	words := make([]string, 0, len(tokens))
+	var prev *token
+	for i := range tokens {
+		tok := &tokens[i]
+		switch tok.kind {
+		case tokenString, tokenNumber, tokenHex, tokenPlaceholder:
+			// unary sign belongs to the number
+			if tok.kind == tokenNumber && prev != nil && prev.kind == tokenOperator && (prev.text == "-" || prev.text == "+") && isUnary(tokens, i-1) {
+				words = words[:len(words)-1]
+			}
+			words = append(words, "?")
+		case tokenWord:
+			upper := strings.ToUpper(tok.text)
+			if keywords[upper] || isFunction(tokens, i) {
+				words = append(words, upper)
+			} else {
+				words = append(words, EscapeID(tok.text, true))
+			}
+		default:
+			words = append(words, tok.text)
+		}
+		prev = tok
+	}
+	return strings.Join(collapseLists(words), " ")
+}
+
+// tableKeywords are followed by a table name, not a function
+var tableKeywords = map[string]bool{"INTO": true, "TABLE": true, "FROM": true, "JOIN": true, "UPDATE": true, "REFERENCES": true}
+
+// isFunction reports whether word at tokens[i] is a function call: ( follows
+// without space and the word is not a table name
+func isFunction(tokens []token, i int) bool {
+	if i+1 >= len(tokens) || tokens[i+1].text != "(" || tokens[i+1].pos != tokens[i].pos+len(tokens[i].text) {
+		return false
+	}
+	return i == 0 || !tableKeywords[strings.ToUpper(tokens[i-1].text)]
+}
+
+// isUnary reports whether sign at tokens[i] is unary
+func isUnary(tokens []token, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := tokens[i-1]
+	switch prev.kind {
+	case tokenOperator:
+		return prev.text != ")"
+	case tokenWord:
+		return keywords[strings.ToUpper(prev.text)]
+	}
+	return false
+}
+
+// collapseLists replaces ( ? , ? ) with (...) and repeated (...) , (...) with (...) /* , ... */
+func collapseLists(words []string) []string {
+	out := words[:0:0]
+	for i := 0; i < len(words); i++ {
+		if words[i] == "(" {
+			j := i + 1
+			for j+1 < len(words) && words[j] == "?" && words[j+1] == "," {
+				j += 2
+			}
+			if j+1 < len(words) && words[j] == "?" && words[j+1] == ")" {
+				out = append(out, "(...)")
+				i = j + 1
+				continue
+			}
+		}
+		out = append(out, words[i])
+	}
+
+	collapsed := out[:0:0]
+	for i := 0; i < len(out); i++ {
+		collapsed = append(collapsed, out[i])
+		if out[i] != "(...)" || i+2 >= len(out) || out[i+1] != "," || out[i+2] != "(...)" {
+			continue
+		}
+		for i+2 < len(out) && out[i+1] == "," && out[i+2] == "(...)" {
+			i += 2
+		}
+		collapsed = append(collapsed, "/* , ... */")
+	}
+	return collapsed
+}
+
+// Fingerprint returns hex SHA-256 digest of Normalize(sqlQuery), statements of
+// the same shape share a fingerprint
+func Fingerprint(sqlQuery string) string {
+	sum := sha256.Sum256([]byte(Normalize(sqlQuery)))
+	return hex.EncodeToString(sum[:])
+}
+
+// keywords reserved and common non-reserved words kept in upper case
+var keywords = make(map[string]bool)
+
+func init() {
+	for _, word := range strings.Fields(`
+		ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN BIGINT BINARY BLOB BOTH BY
+		CALL CASCADE CASE CHANGE CHAR CHARACTER CHECK COLLATE COLUMN CONDITION CONSTRAINT CONTINUE CONVERT
+		CREATE CROSS CUBE CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATABASE DATABASES
+		DAY_HOUR DAY_MICROSECOND DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT DELAYED DELETE DESC
+		DESCRIBE DETERMINISTIC DISTINCT DISTINCTROW DIV DOUBLE DROP DUAL EACH ELSE ELSEIF ENCLOSED ESCAPED
+		EXCEPT EXISTS EXIT EXPLAIN FALSE FETCH FLOAT FOR FORCE FOREIGN FROM FULLTEXT FUNCTION GENERATED GET
+		GRANT GROUP HAVING HIGH_PRIORITY HOUR_MICROSECOND HOUR_MINUTE HOUR_SECOND IF IGNORE IN INDEX INFILE
+		INNER INOUT INSENSITIVE INSERT INT INTEGER INTERSECT INTERVAL INTO IS ITERATE JOIN JSON_TABLE KEY KEYS
+		KILL LATERAL LEADING LEAVE LEFT LIKE LIMIT LINEAR LINES LOAD LOCALTIME LOCALTIMESTAMP LOCK LONG
+		LONGBLOB LONGTEXT LOOP LOW_PRIORITY MATCH MEDIUMBLOB MEDIUMINT MEDIUMTEXT MOD MODIFIES NATURAL NOT
+		NO_WRITE_TO_BINLOG NULL NUMERIC OF OFFSET ON OPTIMIZE OPTION OPTIONALLY OR ORDER OUT OUTER OUTFILE
+		OVER PARTITION PRECISION PRIMARY PROCEDURE PURGE RANGE READ READS REAL RECURSIVE REFERENCES REGEXP
+		RELEASE RENAME REPEAT REPLACE REQUIRE RESIGNAL RESTRICT RETURN REVOKE RIGHT RLIKE SCHEMA SCHEMAS
+		SELECT SENSITIVE SEPARATOR SET SHOW SIGNAL SMALLINT SPATIAL SPECIFIC SQL SQL_CALC_FOUND_ROWS
+		SQLEXCEPTION SQLSTATE SQLWARNING SSL STARTING STORED STRAIGHT_JOIN TABLE TERMINATED THEN TINYBLOB
+		TINYINT TINYTEXT TO TRAILING TRIGGER TRUE UNDO UNION UNIQUE UNLOCK UNSIGNED UPDATE USAGE USE USING
+		UTC_DATE UTC_TIME UTC_TIMESTAMP VALUES VARBINARY VARCHAR VARYING VIRTUAL WHEN WHERE WHILE WINDOW WITH
+		WRITE XOR YEAR_MONTH ZEROFILL
+		AGAINST AUTO_INCREMENT BEGIN BOOLEAN CHARSET COLUMNS COMMIT COMMENT CONSISTENT DATE DATETIME
+		DUPLICATE ENGINE ENUM EXPANSION FIELDS FIRST FULL GLOBAL HASH LANGUAGE LAST LEVEL MODE NAMES
+		NEXT NO ONLY QUERY ROLLBACK ROW ROWS SAVEPOINT SESSION SHARE SNAPSHOT START STATUS TABLES TEMPORARY
+		TEXT TIME TIMESTAMP TRANSACTION TRUNCATE UNKNOWN VALUE VARIABLES VIEW WARNINGS WORK`) {
+		keywords[word] = true
+	}
+}
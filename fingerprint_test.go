@@ -0,0 +1,39 @@
+package mysql
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		sql, want string
+	}{
+		{"select * from users where id = 1", "SELECT * FROM `users` WHERE `id` = ?"},
+		{"SELECT  *\n FROM `users`\tWHERE `id`=?", "SELECT * FROM `users` WHERE `id` = ?"},
+		{"select name from users where name = 'it\\'s' or name = \"a\"\"b\" -- trailing ?\n", "SELECT `name` FROM `users` WHERE `name` = ? OR `name` = ?"},
+		{"select /* hint ? */ x'ff', 0xff, _utf8mb4'a', 1.5e3, -2 # ?", "SELECT ? , ? , ? , ? , ?"},
+		{"select a - 1 from t", "SELECT `a` - ? FROM `t`"},
+		{"select * from t where id in (1, 2, 3) and x not in (?)", "SELECT * FROM `t` WHERE `id` IN (...) AND `x` NOT IN (...)"},
+		{"insert into t(`a`, b) values (1, 'x'), (2, 'y'), (3, 'z')", "INSERT INTO `t` ( `a` , `b` ) VALUES (...) /* , ... */"},
+		{"select count(*), t.id from t limit 10", "SELECT COUNT ( * ) , `t` . `id` FROM `t` LIMIT ?"},
+		{"select @@session.sql_mode, @a := 1", "SELECT @@session.sql_mode , @a := ?"},
+		{"select `a``b` from 1t", "SELECT `a``b` FROM `1t`"},
+	}
+	for _, test := range tests {
+		if got := Normalize(test.sql); got != test.want {
+			t.Errorf("Normalize(%q) = %q, want %q", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("select * from users where id in (1, 2) and name = 'a'")
+	b := Fingerprint("SELECT *\nFROM `users` WHERE id IN (?,?,?) AND name=\"b\" -- by name")
+	if a != b {
+		t.Errorf("fingerprints differ: %s != %s", a, b)
+	}
+	if len(a) != 64 {
+		t.Errorf("fingerprint %q is not a hex SHA-256 digest", a)
+	}
+	if c := Fingerprint("select * from users where id = 1"); c == a {
+		t.Errorf("different statements share fingerprint %s", c)
+	}
+}
@@ -0,0 +1,302 @@
+// Package fixtures loads test data from YAML or JSON files into a mysql.DB.
+//
+// A file maps tables to rows, or holds the rows of the table named after it
+// (users.yml). Files are text/template templates executed on every Load:
+//
+//	users:
+//	  - id: 1
+//	    name: alice
+//	    created: "{{ now }}"
+//	    settings: {theme: dark} # maps and lists are stored as JSON
+//	posts:
+//	  - {id: 1, user_id: 1, title: "{{ .Title }}"}
+//
+// Load truncates the fixture tables, and those given to WithTruncate, with
+// foreign key checks disabled and inserts the rows through DB.Insert:
+//
+//	f, err := fixtures.New(db, os.DirFS("testdata/fixtures"))
+//	f.Setup(t) // load now, truncate at test end
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	mysql "github.com/vinhjaxt/mysql-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Option configures Fixtures
+type Option func(*Fixtures)
+
+// WithData sets data of the file templates
+func WithData(data interface{}) Option {
+	return func(f *Fixtures) {
+		f.data = data
+	}
+}
+
+// WithFuncs adds template functions, "now" is predefined and returns the
+// current time as 2006-01-02 15:04:05
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(f *Fixtures) {
+		for name, fn := range funcs {
+			f.funcs[name] = fn
+		}
+	}
+}
+
+// WithTruncate truncates tables without fixtures too
+func WithTruncate(tables ...string) Option {
+	return func(f *Fixtures) {
+		f.truncate = append(f.truncate, tables...)
+	}
+}
+
+// file fixture file
+type file struct {
+	name     string
+	template *template.Template
+}
+
+// Fixtures fixture files of a database
+type Fixtures struct {
+	db       *mysql.DB
+	files    []file
+	data     interface{}
+	funcs    template.FuncMap
+	truncate []string
+}
+
+// New returns Fixtures of the .yml, .yaml and .json files in the root of fsys
+func New(db *mysql.DB, fsys fs.FS, opts ...Option) (*Fixtures, error) {
+	f := &Fixtures{
+		db: db,
+		funcs: template.FuncMap{
+			"now": func() string { return time.Now().Format("2006-01-02 15:04:05") },
+		},
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		switch path.Ext(entry.Name()) {
+		case ".yml", ".yaml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(entry.Name()).Funcs(f.funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("fixtures: %v", err)
+		}
+		f.files = append(f.files, file{name: entry.Name(), template: tmpl})
+	}
+	return f, nil
+}
+
+// table rows of a table
+type table struct {
+	name string
+	rows []map[string]interface{}
+}
+
+// Load truncates tables and inserts the fixtures, it resets the database
+// to the fixtures each time it is called
+func (f *Fixtures) Load(ctx context.Context) error {
+	tables, err := f.render()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(tables)+len(f.truncate))
+	for _, t := range tables {
+		names = append(names, t.name)
+	}
+	names = append(names, f.truncate...)
+
+	return f.withoutForeignKeys(ctx, func(db *mysql.DB) error {
+		if err := truncate(db, names); err != nil {
+			return err
+		}
+		for _, t := range tables {
+			if err := insert(db, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Truncate empties the fixture tables and those given to WithTruncate
+func (f *Fixtures) Truncate(ctx context.Context) error {
+	tables, err := f.render()
+	if err != nil {
+		return err
+	}
+	names := append([]string(nil), f.truncate...)
+	for _, t := range tables {
+		names = append(names, t.name)
+	}
+	return f.withoutForeignKeys(ctx, func(db *mysql.DB) error {
+		return truncate(db, names)
+	})
+}
+
+// Setup loads the fixtures for test t and truncates the tables when it ends
+func (f *Fixtures) Setup(t testing.TB) {
+	t.Helper()
+	if err := f.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := f.Truncate(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// withoutForeignKeys calls fn with db bound to a connection without foreign key checks
+func (f *Fixtures) withoutForeignKeys(ctx context.Context, fn func(db *mysql.DB) error) (err error) {
+	conn, err := f.db.Conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	db := f.db.WithConn(conn).WithContext(ctx)
+	if _, err := db.Query("SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, resetErr := db.WithContext(context.Background()).Query("SET FOREIGN_KEY_CHECKS = 1"); err == nil {
+			err = resetErr
+		}
+	}()
+	return fn(db)
+}
+
+// render executes file templates and returns tables in file order
+func (f *Fixtures) render() ([]table, error) {
+	var tables []table
+	index := make(map[string]int)
+	add := func(name string, rows []map[string]interface{}) {
+		i, ok := index[name]
+		if !ok {
+			i = len(tables)
+			index[name] = i
+			tables = append(tables, table{name: name})
+		}
+		tables[i].rows = append(tables[i].rows, rows...)
+	}
+
+	for _, file := range f.files {
+		var buf bytes.Buffer
+		if err := file.template.Execute(&buf, f.data); err != nil {
+			return nil, fmt.Errorf("fixtures: %v", err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			return nil, fmt.Errorf("fixtures: %s: %v", file.name, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		switch root.Kind {
+		case yaml.SequenceNode:
+			var rows []map[string]interface{}
+			if err := root.Decode(&rows); err != nil {
+				return nil, fmt.Errorf("fixtures: %s: %v", file.name, err)
+			}
+			add(strings.TrimSuffix(file.name, path.Ext(file.name)), rows)
+		case yaml.MappingNode:
+			// keep table order of the file
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				var rows []map[string]interface{}
+				if err := root.Content[i+1].Decode(&rows); err != nil {
+					return nil, fmt.Errorf("fixtures: %s: table %s: %v", file.name, root.Content[i].Value, err)
+				}
+				add(root.Content[i].Value, rows)
+			}
+		default:
+			return nil, fmt.Errorf("fixtures: %s: want tables or rows", file.name)
+		}
+	}
+	return tables, nil
+}
+
+// truncate empties tables
+func truncate(db *mysql.DB, tables []string) error {
+	seen := make(map[string]bool, len(tables))
+	for _, name := range tables {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if _, err := db.Query("TRUNCATE TABLE " + mysql.EscapeID(name, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert inserts rows of t, consecutive rows with the same columns share a statement
+func insert(db *mysql.DB, t table) error {
+	var columns []string
+	var batch []interface{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := db.Insert(t.name, columns, batch)
+		batch = batch[:0]
+		return err
+	}
+	for _, row := range t.rows {
+		keys := make([]string, 0, len(row))
+		for key := range row {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, columns) {
+			if err := flush(); err != nil {
+				return err
+			}
+			columns = keys
+		}
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = value(row[key])
+		}
+		batch = append(batch, values)
+	}
+	return flush()
+}
+
+// value returns value of a fixture column, maps and lists are JSON
+func value(v interface{}) interface{} {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return mysql.JSON(v)
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+package fixtures
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vinhjaxt/mysql-go/mysqltest"
+)
+
+// withNow returns a regexp of sql matching NOW as any datetime literal
+func withNow(sql string) string {
+	return "^" + strings.ReplaceAll(regexp.QuoteMeta(sql), "NOW", `'\d{4}-\d\d-\d\d \d\d:\d\d:\d\d'`) + "$"
+}
+
+func TestFixtures(t *testing.T) {
+	db := mysqltest.NewFake(t)
+	db.ExpectExec("SET FOREIGN_KEY_CHECKS = 0")
+	db.ExpectExec("TRUNCATE TABLE `users`")
+	db.ExpectExec("TRUNCATE TABLE `posts`")
+	db.ExpectExec("TRUNCATE TABLE `tags`")
+	db.ExpectExec("TRUNCATE TABLE `audit`")
+	db.ExpectExec(withNow("insert `users` (`created`, `id`, `name`) values (NOW, '1', 'alice'), (NOW, '2', 'it\\'s')")).MatchRegexp()
+	db.ExpectExec("insert `users` (`id`, `name`, `settings`) values ('3', 'carol', '{\\\"theme\\\":\\\"dark\\\"}')")
+	db.ExpectExec("insert `posts` (`id`, `title`, `user_id`) values ('1', 'hello', '1')")
+	db.ExpectExec("insert `tags` (`aliases`, `id`, `name`) values ('[\\\"golang\\\"]', '1', 'go')")
+	db.ExpectExec("SET FOREIGN_KEY_CHECKS = 1")
+
+	f, err := New(db.DB, os.DirFS("testdata"), WithData(map[string]string{"Name": "alice"}), WithTruncate("audit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Conn.Stats().OpenConnections; n != 1 {
+		t.Errorf("used %d connections, want 1", n)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	db := mysqltest.NewFake(t)
+	f, err := New(db.DB, os.DirFS("testdata"), WithData(map[string]string{"Name": "bob"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("test", func(t *testing.T) {
+		db.ExpectExec("SET FOREIGN_KEY_CHECKS = 0")
+		for _, table := range []string{"users", "posts", "tags"} {
+			db.ExpectExec("TRUNCATE TABLE `" + table + "`")
+		}
+		for _, table := range []string{"users", "users", "posts", "tags"} {
+			db.ExpectExec("^insert `" + table + "` ").MatchRegexp()
+		}
+		db.ExpectExec("SET FOREIGN_KEY_CHECKS = 1")
+		f.Setup(t)
+		if err := db.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+
+		// cleanup
+		db.ExpectExec("SET FOREIGN_KEY_CHECKS = 0")
+		for _, table := range []string{"users", "posts", "tags"} {
+			db.ExpectExec("TRUNCATE TABLE `" + table + "`")
+		}
+		db.ExpectExec("SET FOREIGN_KEY_CHECKS = 1")
+	})
+}
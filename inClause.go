@@ -0,0 +1,138 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ErrEmptyList returned when an empty slice is bound to a placeholder
+var ErrEmptyList = errors.New("mysql: empty slice bound to placeholder")
+
+// inListPrefix matches `expr IN (` or `expr NOT IN (` at the end of the query built so far
+var inListPrefix = regexp.MustCompile("(?i)((?:`(?:[^`]|``)*`|[\\w$]+)(?:\\.(?:`(?:[^`]|``)*`|[\\w$]+))*)\\s+(not\\s+)?in\\s*\\(\\s*$")
+
+// placeholderIndexes returns byte offsets of ? placeholders outside
+// quoted strings, quoted identifiers and comments
+func placeholderIndexes(query string) []int {
+	var indexes []int
+	for _, tok := range tokenize(query) {
+		if tok.kind == tokenPlaceholder {
+			indexes = append(indexes, tok.pos)
+		}
+	}
+	return indexes
+}
+
+// expandable reports whether arg is a slice that should expand into a placeholder list
+func expandable(arg interface{}) (reflect.Value, bool) {
+	if arg == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(arg)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return reflect.Value{}, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		// []byte is a binary value
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+// expandArgs expands slice arguments bound to a single ? into (?, ?, ?),
+// slices longer than max bound to `expr IN (?)` split into several IN lists
+func expandArgs(query string, args []interface{}, max int) (string, []interface{}, error) {
+	expand := false
+	for _, arg := range args {
+		if _, ok := expandable(arg); ok {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return query, args, nil
+	}
+
+	indexes := placeholderIndexes(query)
+	if len(indexes) != len(args) {
+		return "", nil, fmt.Errorf("mysql: query has %d placeholders but %d args", len(indexes), len(args))
+	}
+
+	var sql strings.Builder
+	var values []interface{}
+	last := 0
+	for n, index := range indexes {
+		sql.WriteString(query[last:index])
+		last = index + 1
+
+		rv, ok := expandable(args[n])
+		if !ok {
+			sql.WriteByte('?')
+			values = append(values, args[n])
+			continue
+		}
+		length := rv.Len()
+		if length == 0 {
+			return "", nil, ErrEmptyList
+		}
+		for i := 0; i < length; i++ {
+			values = append(values, rv.Index(i).Interface())
+		}
+		if length <= max || max <= 0 {
+			sql.WriteString(placeholderList(length))
+			continue
+		}
+
+		// split into `(expr IN (...) OR expr IN (...))`
+		built := sql.String()
+		match := inListPrefix.FindStringSubmatchIndex(built)
+		closing := strings.TrimLeft(query[last:], " \t\r\n")
+		if match == nil || !strings.HasPrefix(closing, ")") {
+			sql.WriteString(placeholderList(length))
+			continue
+		}
+		expr := built[match[2]:match[3]]
+		operator := " IN ("
+		join := ") OR " + expr + operator
+		if match[4] >= 0 {
+			operator = " NOT IN ("
+			join = ") AND " + expr + operator
+		}
+		sql.Reset()
+		sql.WriteString(built[:match[0]])
+		sql.WriteString("(" + expr + operator)
+		for i := 0; i < length; i += max {
+			if i != 0 {
+				sql.WriteString(join)
+			}
+			size := length - i
+			if size > max {
+				size = max
+			}
+			sql.WriteString(placeholderList(size))
+		}
+		sql.WriteString(")")
+		// consume the closing bracket of the original IN list
+		last = len(query) - len(closing) + 1
+		sql.WriteString(")")
+	}
+	sql.WriteString(query[last:])
+	return sql.String(), values, nil
+}
+
+func placeholderList(n int) string {
+	return strings.Repeat("?, ", n-1) + "?"
+}
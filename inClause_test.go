@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	tests := []struct {
+		query     string
+		args      []interface{}
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{"select ?", []interface{}{1}, "select ?", []interface{}{1}},
+		{"select * from t where id in (?)", []interface{}{[]int{1, 2, 3}}, "select * from t where id in (?, ?, ?)", []interface{}{1, 2, 3}},
+		{"select '?', `?` -- ?\n, ? # ?\n, /* ? */ ? from t where a in (?)", []interface{}{"x", "y", []string{"a", "b"}}, "select '?', `?` -- ?\n, ? # ?\n, /* ? */ ? from t where a in (?, ?)", []interface{}{"x", "y", "a", "b"}},
+		{"select 'it''s ?', ? from t where a in (?)", []interface{}{[]byte("raw"), [2]int{1, 2}}, "select 'it''s ?', ? from t where a in (?, ?)", []interface{}{[]byte("raw"), 1, 2}},
+	}
+	for _, test := range tests {
+		query, args, err := expandArgs(test.query, test.args, DefaultMaxInListSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.wantQuery || !reflect.DeepEqual(args, test.wantArgs) {
+			t.Errorf("expandArgs(%q) = %q %v, want %q %v", test.query, query, args, test.wantQuery, test.wantArgs)
+		}
+	}
+
+	if _, _, err := expandArgs("select * from t where id in (?)", []interface{}{[]int{}}, DefaultMaxInListSize); err != ErrEmptyList {
+		t.Errorf("empty slice: got %v, want ErrEmptyList", err)
+	}
+}
+
+func TestExpandArgsSplit(t *testing.T) {
+	query, args, err := expandArgs("select * from t where `t`.`id` in ( ? ) and x = ?", []interface{}{[]int{1, 2, 3}, 4}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "select * from t where (`t`.`id` IN (?, ?) OR `t`.`id` IN (?)) and x = ?"
+	if query != want || len(args) != 4 {
+		t.Errorf("got %q %v, want %q", query, args, want)
+	}
+
+	query, _, err = expandArgs("delete from t where id NOT IN (?)", []interface{}{[]int{1, 2, 3}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "delete from t where (id NOT IN (?, ?) AND id NOT IN (?))"
+	if query != want {
+		t.Errorf("got %q, want %q", query, want)
+	}
+}
+
+func TestUpdateDeleteInList(t *testing.T) {
+	db, d := newTestDB(t)
+	db.maxInListSize = 2
+
+	if _, err := db.Update("users", map[string]interface{}{"active": 0}, map[string]interface{}{"id": []int{1, 2, 3}, "org": 7}); err != nil {
+		t.Fatal(err)
+	}
+	want := "update `users` set `active`=? where (`id` IN (?, ?) OR `id` IN (?)) and `org`=?"
+	if got := d.executed(); !reflect.DeepEqual(got, []string{want}) {
+		t.Errorf("update: got %q, want %q", got, want)
+	}
+	if wantArgs := []driver.Value{int64(0), int64(1), int64(2), int64(3), int64(7)}; !reflect.DeepEqual(d.lastArgs(), wantArgs) {
+		t.Errorf("update args: got %v, want %v", d.lastArgs(), wantArgs)
+	}
+
+	if _, err := db.Delete("users", Condition{SQL: "id NOT IN (?)", Args: []interface{}{[]int{1, 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "delete from `users` where (id NOT IN (?, ?))"
+	if got := d.executed(); !reflect.DeepEqual(got, []string{want}) {
+		t.Errorf("delete: got %q, want %q", got, want)
+	}
+
+	if _, err := db.Delete("users", map[string]interface{}{"id": []int{}}); err != ErrEmptyList {
+		t.Errorf("delete empty slice: got %v, want ErrEmptyList", err)
+	}
+}
@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Op operation kind of a statement run by DB
+type Op string
+
+// Operation kinds, named after DB helpers
+const (
+	OpSingle       Op = "single"
+	OpRow          Op = "row"
+	OpRows         Op = "rows"
+	OpSetRows      Op = "setrows"
+	OpSetRowsNil   Op = "setrowsnil"
+	OpScanRow      Op = "scanrow"
+	OpScanRows     Op = "scanrows"
+	OpInsert       Op = "insert"
+	OpInsertUpdate Op = "insertupdate"
+	OpUpdate       Op = "update"
+	OpDelete       Op = "delete"
+	OpQuery        Op = "query"
+	OpScript       Op = "script"
+	OpBegin        Op = "begin"
+	OpCommit       Op = "commit"
+	OpRollback     Op = "rollback"
+)
+
+// QueryInfo describes a statement run by DB
+type QueryInfo struct {
+	Op    Op
+	Table string // empty for raw sql helpers
+	SQL   string
+	Args  []interface{}
+	InTx  bool // statement runs in a transaction
+
+	Start time.Time
+	// Duration and RowsAffected are set before After is called,
+	// RowsAffected is the number of rows returned by selects
+	Duration     time.Duration
+	RowsAffected int64
+
+	esc escaper // of the DB, renders args like the statement would
+}
+
+// Interceptor observes and alters every statement run by DB and Tx.
+// Before may change info.SQL and info.Args before the statement runs and
+// returns the context passed to the driver and to After. Changes are ignored
+// for OpBegin, OpCommit and OpRollback, run by database/sql itself.
+// Interceptors run Before in registration order and After in reverse order
+type Interceptor interface {
+	Before(ctx context.Context, info *QueryInfo) context.Context
+	After(ctx context.Context, info *QueryInfo, result sql.Result, err error)
+}
+
+// WithInterceptors registers interceptors on DB
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// call a statement in progress
+type call struct {
+	db   *DB
+	ctx  context.Context
+	info QueryInfo
+}
+
+// before starts a call and runs Before of interceptors
+func (db *DB) before(op Op, table, sqlQuery string, args []interface{}) *call {
+	c := &call{
+		db:  db,
+		ctx: db.context(),
+		info: QueryInfo{
+			Op:    op,
+			Table: table,
+			SQL:   sqlQuery,
+			Args:  args,
+			InTx:  db.tx != nil,
+			Start: time.Now(),
+			esc:   db.esc,
+		},
+	}
+	for _, interceptor := range db.interceptors {
+		c.ctx = interceptor.Before(c.ctx, &c.info)
+	}
+	return c
+}
+
+// after ends a call and runs After of interceptors, rows is used when result is nil
+func (c *call) after(result sql.Result, rows int64, err error) {
+	c.info.Duration = time.Since(c.info.Start)
+	c.info.RowsAffected = rows
+	if result != nil {
+		if n, err := result.RowsAffected(); err == nil {
+			c.info.RowsAffected = n
+		}
+	}
+	for i := len(c.db.interceptors) - 1; i >= 0; i-- {
+		c.db.interceptors[i].After(c.ctx, &c.info, result, err)
+	}
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type ctxKey string
+
+// recorder records calls and tags the context in Before
+type recorder struct {
+	name  string
+	calls *[]string
+	infos *[]QueryInfo
+}
+
+func (r recorder) Before(ctx context.Context, info *QueryInfo) context.Context {
+	*r.calls = append(*r.calls, r.name+".before")
+	if r.name == "a" {
+		info.SQL += " /* a */"
+	}
+	return context.WithValue(ctx, ctxKey(r.name), true)
+}
+
+func (r recorder) After(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+	*r.calls = append(*r.calls, r.name+".after")
+	if ctx.Value(ctxKey(r.name)) == nil {
+		*r.calls = append(*r.calls, r.name+".lostctx")
+	}
+	if r.name == "a" {
+		*r.infos = append(*r.infos, *info)
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	var calls []string
+	var infos []QueryInfo
+	db, d := newTestDB(t, anyRows)
+	d.setAffected(3)
+	db.stmts = newStmtCache(4)
+	db.interceptors = []Interceptor{
+		recorder{"a", &calls, &infos},
+		recorder{"b", &calls, &infos},
+	}
+
+	if _, err := db.Rows("select n from t where id in (?)", []int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.before", "b.before", "b.after", "a.after"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Update("users", map[string]string{"name": "x"}, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Single("select 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{"Close", "Begin", "WithConn", "Dump"} {
+		if _, ok := reflect.TypeOf(tx).MethodByName(method); ok {
+			t.Errorf("Tx has method %s running outside the transaction", method)
+		}
+	}
+
+	want := []QueryInfo{
+		{Op: OpRows, SQL: "select n from t where id in (?, ?) /* a */", Args: []interface{}{1, 2}, RowsAffected: 2},
+		{Op: OpBegin, SQL: "START TRANSACTION /* a */"},
+		{Op: OpUpdate, Table: "users", SQL: "update `users` set `name`=? where `id`=? /* a */", Args: []interface{}{"x", 1}, InTx: true, RowsAffected: 3},
+		{Op: OpSingle, SQL: "select 1 /* a */", InTx: true, RowsAffected: 1},
+		{Op: OpCommit, SQL: "COMMIT /* a */", InTx: true},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("got %d infos, want %d", len(infos), len(want))
+	}
+	for i := range infos {
+		infos[i].Start, infos[i].Duration = want[i].Start, want[i].Duration
+		if !reflect.DeepEqual(infos[i], want[i]) {
+			t.Errorf("info %d = %+v, want %+v", i, infos[i], want[i])
+		}
+	}
+	for _, call := range calls {
+		if call == "a.lostctx" || call == "b.lostctx" {
+			t.Errorf("context from Before not passed to After")
+		}
+	}
+}
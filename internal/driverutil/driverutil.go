@@ -0,0 +1,99 @@
+// Package driverutil holds database/sql/driver values shared by drivers
+// answering from memory, such as mysqltest.Fake and replay.Replayer
+package driverutil
+
+import (
+	"database/sql/driver"
+	"io"
+	"reflect"
+)
+
+// CheckNamedValue keeps unsigned integers uint64 like go-sql-driver, other
+// values are left to the default conversion of database/sql
+func CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+	switch rv := reflect.ValueOf(nv.Value); rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		nv.Value = rv.Uint()
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// Named returns args numbered from 1
+func Named(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return nvs
+}
+
+// Unnamed returns values of args
+func Unnamed(args []driver.NamedValue) []driver.Value {
+	vs := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vs[i] = arg.Value
+	}
+	return vs
+}
+
+// Result driver.Result of an exec
+type Result struct {
+	InsertID int64
+	Affected int64
+}
+
+func (r Result) LastInsertId() (int64, error) { return r.InsertID, nil }
+func (r Result) RowsAffected() (int64, error) { return r.Affected, nil }
+
+// ResultSet columns and rows of a query
+type ResultSet struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+// Rows driver.Rows reading result sets in order
+type Rows struct {
+	sets []ResultSet
+	set  int // current result set
+	row  int // next row of the set
+}
+
+// NewRows returns rows of sets
+func NewRows(sets ...ResultSet) *Rows {
+	return &Rows{sets: sets}
+}
+
+func (r *Rows) Columns() []string {
+	if r.set >= len(r.sets) {
+		return nil
+	}
+	return r.sets[r.set].Columns
+}
+
+func (r *Rows) Close() error { return nil }
+
+func (r *Rows) Next(dest []driver.Value) error {
+	if r.set >= len(r.sets) || r.row >= len(r.sets[r.set].Rows) {
+		return io.EOF
+	}
+	copy(dest, r.sets[r.set].Rows[r.row])
+	r.row++
+	return nil
+}
+
+func (r *Rows) HasNextResultSet() bool {
+	return r.set+1 < len(r.sets)
+}
+
+func (r *Rows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set++
+	r.row = 0
+	return nil
+}
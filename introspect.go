@@ -0,0 +1,159 @@
+package mysql
+
+import "errors"
+
+// schemaCondition selects the configured database, or the current one of
+// a DB built without config
+const schemaCondition = "TABLE_SCHEMA = IFNULL(NULLIF(?, ''), DATABASE())"
+
+// Table table or view of the database
+type Table struct {
+	Name          string `db:"name"`
+	Type          string `db:"type"` // BASE TABLE or VIEW
+	Engine        string `db:"engine"`
+	Collation     string `db:"collation"`
+	AutoIncrement *int64 `db:"auto_increment"` // next value, nil without auto_increment column
+	Comment       string `db:"comment"`
+}
+
+// Column column of a table
+type Column struct {
+	Name         string  `db:"name"`
+	Position     int     `db:"position"`
+	Type         string  `db:"type"`      // full type: varchar(255), int unsigned
+	DataType     string  `db:"data_type"` // type name: varchar, int
+	Nullable     bool    `db:"nullable"`
+	Default      *string `db:"default"` // nil without default
+	CharacterSet string  `db:"character_set"`
+	Collation    string  `db:"collation"`
+	Key          string  `db:"key"`       // PRI, UNI or MUL
+	Extra        string  `db:"extra"`     // auto_increment, on update CURRENT_TIMESTAMP, VIRTUAL GENERATED...
+	Generated    string  `db:"generated"` // expression of generated columns
+	Comment      string  `db:"comment"`
+}
+
+// IndexColumn column of an index
+type IndexColumn struct {
+	Name   string // empty for functional key parts
+	Length int    // prefix length, 0 for whole column
+}
+
+// Index index of a table, PRIMARY for the primary key
+type Index struct {
+	Name    string
+	Unique  bool
+	Type    string // BTREE, HASH, FULLTEXT or SPATIAL
+	Columns []IndexColumn
+	Comment string
+}
+
+// ForeignKey foreign key constraint of a table
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// Tables returns tables and views of the database in name order
+func (db *DB) Tables() (tables []Table, err error) {
+	err = db.ScanRows(&tables, `SELECT TABLE_NAME AS name, TABLE_TYPE AS type, IFNULL(ENGINE, '') AS engine,
+		IFNULL(TABLE_COLLATION, '') AS collation, AUTO_INCREMENT AS auto_increment, IFNULL(TABLE_COMMENT, '') AS comment
+		FROM information_schema.TABLES WHERE `+schemaCondition+` ORDER BY TABLE_NAME`, db.dbName)
+	return
+}
+
+// Columns returns columns of table in position order
+func (db *DB) Columns(table string) (columns []Column, err error) {
+	err = db.ScanRows(&columns, `SELECT COLUMN_NAME AS name, ORDINAL_POSITION AS position, COLUMN_TYPE AS type,
+		DATA_TYPE AS data_type, IS_NULLABLE = 'YES' AS nullable, COLUMN_DEFAULT AS `+"`default`"+`,
+		IFNULL(CHARACTER_SET_NAME, '') AS character_set, IFNULL(COLLATION_NAME, '') AS collation,
+		COLUMN_KEY AS `+"`key`"+`, EXTRA AS extra, IFNULL(GENERATION_EXPRESSION, '') AS generated, COLUMN_COMMENT AS comment
+		FROM information_schema.COLUMNS WHERE `+schemaCondition+` AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`, db.dbName, table)
+	return
+}
+
+// Indexes returns indexes of table, primary key first then in name order
+func (db *DB) Indexes(table string) ([]Index, error) {
+	var rows []struct {
+		Name    string `db:"name"`
+		Unique  bool   `db:"unique"`
+		Type    string `db:"type"`
+		Column  string `db:"column"`
+		Length  int    `db:"length"`
+		Comment string `db:"comment"`
+	}
+	err := db.ScanRows(&rows, `SELECT INDEX_NAME AS name, NON_UNIQUE = 0 AS `+"`unique`"+`, INDEX_TYPE AS type,
+		IFNULL(COLUMN_NAME, '') AS `+"`column`"+`, IFNULL(SUB_PART, 0) AS length, INDEX_COMMENT AS comment
+		FROM information_schema.STATISTICS WHERE `+schemaCondition+` AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME != 'PRIMARY', INDEX_NAME, SEQ_IN_INDEX`, db.dbName, table)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []Index
+	for _, row := range rows {
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != row.Name {
+			indexes = append(indexes, Index{Name: row.Name, Unique: row.Unique, Type: row.Type, Comment: row.Comment})
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, IndexColumn{Name: row.Column, Length: row.Length})
+	}
+	return indexes, nil
+}
+
+// ForeignKeys returns foreign keys of table in name order
+func (db *DB) ForeignKeys(table string) ([]ForeignKey, error) {
+	var rows []struct {
+		Name      string `db:"name"`
+		Column    string `db:"column"`
+		RefTable  string `db:"ref_table"`
+		RefColumn string `db:"ref_column"`
+		OnUpdate  string `db:"on_update"`
+		OnDelete  string `db:"on_delete"`
+	}
+	err := db.ScanRows(&rows, `SELECT k.CONSTRAINT_NAME AS name, k.COLUMN_NAME AS `+"`column`"+`,
+		k.REFERENCED_TABLE_NAME AS ref_table, k.REFERENCED_COLUMN_NAME AS ref_column,
+		r.UPDATE_RULE AS on_update, r.DELETE_RULE AS on_delete
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+		ON r.CONSTRAINT_SCHEMA = k.TABLE_SCHEMA AND r.TABLE_NAME = k.TABLE_NAME AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+		WHERE k.`+schemaCondition+` AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`, db.dbName, table)
+	if err != nil {
+		return nil, err
+	}
+	var keys []ForeignKey
+	for _, row := range rows {
+		if len(keys) == 0 || keys[len(keys)-1].Name != row.Name {
+			keys = append(keys, ForeignKey{Name: row.Name, RefTable: row.RefTable, OnUpdate: row.OnUpdate, OnDelete: row.OnDelete})
+		}
+		key := &keys[len(keys)-1]
+		key.Columns = append(key.Columns, row.Column)
+		key.RefColumns = append(key.RefColumns, row.RefColumn)
+	}
+	return keys, nil
+}
+
+// CreateStatement returns SHOW CREATE TABLE of table, or of view
+func (db *DB) CreateStatement(table string) (string, error) {
+	row, err := db.Row("SHOW CREATE TABLE " + db.qualified(table))
+	if err != nil {
+		return "", err
+	}
+	for _, column := range []string{"Create Table", "Create View"} {
+		if create, ok := row[column]; ok && create != nil && create.Valid {
+			return create.String, nil
+		}
+	}
+	return "", errors.New("mysql: no create statement of " + table)
+}
+
+// qualified returns escaped table prefixed with the configured database
+func (db *DB) qualified(table string) string {
+	if db.dbName == "" {
+		return EscapeID(table, true)
+	}
+	return EscapeID(db.dbName, true) + "." + EscapeID(table, true)
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestIntrospection(t *testing.T) {
+	db, d := newTestDB(t,
+		result("information_schema.TABLES", []string{"name", "type", "engine", "collation", "auto_increment", "comment"},
+			[]driver.Value{"users", "BASE TABLE", "InnoDB", "utf8mb4_unicode_ci", int64(5), ""},
+			[]driver.Value{"v", "VIEW", "", "", nil, "VIEW"},
+		),
+		result("information_schema.COLUMNS", []string{"name", "position", "type", "data_type", "nullable", "default", "character_set", "collation", "key", "extra", "generated", "comment"},
+			[]driver.Value{"id", int64(1), "int unsigned", "int", int64(0), nil, "", "", "PRI", "auto_increment", "", ""},
+			[]driver.Value{"name", int64(2), "varchar(64)", "varchar", int64(1), "x", "utf8mb4", "utf8mb4_unicode_ci", "", "", "", "full name"},
+		),
+		result("information_schema.STATISTICS", []string{"name", "unique", "type", "column", "length", "comment"},
+			[]driver.Value{"PRIMARY", int64(1), "BTREE", "id", int64(0), ""},
+			[]driver.Value{"name_email", int64(0), "BTREE", "name", int64(10), ""},
+			[]driver.Value{"name_email", int64(0), "BTREE", "email", int64(0), ""},
+		),
+		result("information_schema.KEY_COLUMN_USAGE", []string{"name", "column", "ref_table", "ref_column", "on_update", "on_delete"},
+			[]driver.Value{"fk_org", "org_id", "orgs", "id", "RESTRICT", "CASCADE"},
+			[]driver.Value{"fk_org", "org_region", "orgs", "region", "RESTRICT", "CASCADE"},
+		),
+		result("SHOW CREATE TABLE `app`.`users`", []string{"Table", "Create Table"}, []driver.Value{"users", "CREATE TABLE `users` (...)"}),
+	)
+
+	tables, err := db.Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auto := int64(5)
+	wantTables := []Table{{Name: "users", Type: "BASE TABLE", Engine: "InnoDB", Collation: "utf8mb4_unicode_ci", AutoIncrement: &auto}, {Name: "v", Type: "VIEW", Comment: "VIEW"}}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Errorf("Tables() = %+v, want %+v", tables, wantTables)
+	}
+	if args := d.lastArgs(); !reflect.DeepEqual(args, []driver.Value{"app"}) {
+		t.Errorf("Tables() args = %v, want [app]", args)
+	}
+
+	columns, err := db.Columns("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 2 || columns[0].Nullable || columns[0].Default != nil || !columns[1].Nullable || columns[1].Default == nil || *columns[1].Default != "x" || columns[1].Comment != "full name" {
+		t.Errorf("Columns() = %+v", columns)
+	}
+
+	indexes, err := db.Indexes("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIndexes := []Index{
+		{Name: "PRIMARY", Unique: true, Type: "BTREE", Columns: []IndexColumn{{Name: "id"}}},
+		{Name: "name_email", Type: "BTREE", Columns: []IndexColumn{{Name: "name", Length: 10}, {Name: "email"}}},
+	}
+	if !reflect.DeepEqual(indexes, wantIndexes) {
+		t.Errorf("Indexes() = %+v, want %+v", indexes, wantIndexes)
+	}
+
+	keys, err := db.ForeignKeys("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKeys := []ForeignKey{{Name: "fk_org", Columns: []string{"org_id", "org_region"}, RefTable: "orgs", RefColumns: []string{"id", "region"}, OnUpdate: "RESTRICT", OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("ForeignKeys() = %+v, want %+v", keys, wantKeys)
+	}
+
+	create, err := db.CreateStatement("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if create != "CREATE TABLE `users` (...)" {
+		t.Errorf("CreateStatement() = %q", create)
+	}
+}
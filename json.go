@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// JSONValue value stored in or scanned from a JSON column, see JSON
+type JSONValue struct {
+	V    interface{}
+	Cast bool // escape as CAST('...' AS JSON) instead of a string literal
+}
+
+// JSON wraps v to be escaped as JSON string literal, or to be scanned from a
+// JSON column when v is a pointer:
+//
+//	db.Insert("t", []string{"meta"}, []interface{}{[]interface{}{mysql.JSON(meta)}})
+//	db.ScanRow(&struct{ Meta map[string]interface{} `db:"meta,json"` }{}, ...)
+//	db.Conn.QueryRow("select meta from t").Scan(mysql.JSON(&meta))
+func JSON(v interface{}) JSONValue {
+	return JSONValue{V: v}
+}
+
+// CastJSON is JSON wrapped in CAST(... AS JSON)
+func CastJSON(v interface{}) JSONValue {
+	return JSONValue{V: v, Cast: true}
+}
+
+// marshal encodes j.V without escaping HTML characters
+func (j JSONValue) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(j.V); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Value implements driver.Valuer, encodes V as JSON string
+func (j JSONValue) Value() (driver.Value, error) {
+	data, err := j.marshal()
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner, decodes JSON column into V which must be a pointer.
+// NULL leaves V unchanged
+func (j JSONValue) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.V)
+	case string:
+		return json.Unmarshal([]byte(v), j.V)
+	}
+	return fmt.Errorf("mysql: can not scan %T into JSON", src)
+}
+
+// appendJSON appends j as escaped JSON string literal
+func (e escaper) appendJSON(buf []byte, j JSONValue) ([]byte, error) {
+	data, err := j.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if j.Cast {
+		buf = append(buf, "CAST("...)
+	}
+	buf = e.appendBytes(buf, data)
+	if j.Cast {
+		buf = append(buf, " AS JSON)"...)
+	}
+	return buf, nil
+}
+
+// isJSONObject reports whether v is a map, struct or list to be stored as JSON,
+// time, binary and driver.Valuer values are not
+func isJSONObject(v interface{}) bool {
+	switch v.(type) {
+	case nil, driver.Valuer, time.Time, *time.Time:
+		return false
+	}
+	if _, ok := asBytes(v); ok {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
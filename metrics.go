@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// MetricsSink metrics backend, see NewExpvarSink and package prommysql
+type MetricsSink interface {
+	// SetPoolStats exports connection pool statistics
+	SetPoolStats(stats sql.DBStats)
+	// ObserveQuery records a statement of op on table, err is nil on success
+	ObserveQuery(op Op, table string, duration time.Duration, err error)
+}
+
+// Metrics counts statements and exports pool statistics to a MetricsSink.
+// Register it with WithInterceptors and call Collect once DB is created:
+//
+//	metrics := mysql.NewMetrics(mysql.NewExpvarSink("mysql"))
+//	db, err := mysql.New(config, mysql.WithInterceptors(metrics))
+//	metrics.Collect(db, 10*time.Second)
+//	defer metrics.Stop()
+type Metrics struct {
+	sink MetricsSink
+
+	mu         sync.Mutex
+	collecting bool // Collect goroutine started
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewMetrics returns Metrics exporting to sink
+func NewMetrics(sink MetricsSink) *Metrics {
+	return &Metrics{
+		sink: sink,
+		stop: make(chan struct{}),
+	}
+}
+
+// Before implements Interceptor
+func (m *Metrics) Before(ctx context.Context, info *QueryInfo) context.Context {
+	return ctx
+}
+
+// After implements Interceptor, sql.ErrNoRows is not counted as error
+func (m *Metrics) After(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	m.sink.ObserveQuery(info.Op, info.Table, info.Duration, err)
+}
+
+// Collect exports pool statistics of db now and every interval until Stop,
+// only the first call starts collecting, later ones export once
+func (m *Metrics) Collect(db *DB, interval time.Duration) {
+	m.sink.SetPoolStats(db.Conn.Stats())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.collecting {
+		return
+	}
+	select {
+	case <-m.stop:
+		return // stopped
+	default:
+	}
+	m.collecting = true
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.sink.SetPoolStats(db.Conn.Stats())
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends collecting pool statistics
+func (m *Metrics) Stop() {
+	m.mu.Lock()
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+}
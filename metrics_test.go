@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestMetricsExpvar(t *testing.T) {
+	metrics := NewMetrics(NewExpvarSink("mysql_test"))
+	db, _ := newTestDB(t, anyRows)
+	db.interceptors = []Interceptor{metrics}
+	metrics.Collect(db, time.Millisecond)
+	metrics.Collect(db, time.Millisecond)
+	defer metrics.Stop()
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.Rows("select n from t"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Delete("t", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	metrics.Stop()
+
+	var got struct {
+		Pool    map[string]int64
+		Queries map[string]map[string]float64
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("mysql_test").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Queries["rows"]["count"] != 3 || got.Queries["delete"]["count"] != 1 || got.Queries["rows"]["errors"] != 0 {
+		t.Errorf("queries = %v", got.Queries)
+	}
+	if got.Queries["rows"]["le_10"] != 3 {
+		t.Errorf("latency buckets = %v", got.Queries["rows"])
+	}
+	if _, ok := got.Pool["open"]; !ok {
+		t.Errorf("pool = %v", got.Pool)
+	}
+}
+
+func TestMetricsCollectOnce(t *testing.T) {
+	db, _ := newTestDB(t)
+	sink := &countingSink{}
+	metrics := NewMetrics(sink)
+	for i := 0; i < 3; i++ {
+		metrics.Collect(db, time.Hour)
+	}
+	metrics.Stop()
+	metrics.Collect(db, time.Hour)
+	if sink.stats != 4 {
+		t.Errorf("SetPoolStats called %d times, want 4", sink.stats)
+	}
+}
+
+func TestExpvarSinkReused(t *testing.T) {
+	NewExpvarSink("mysql_test_reused").ObserveQuery(OpRows, "t", time.Millisecond, nil)
+	NewExpvarSink("mysql_test_reused")
+	if got := expvar.Get("mysql_test_reused").String(); got != `{"pool": {}, "queries": {}}` {
+		t.Errorf("reused sink = %s", got)
+	}
+}
+
+// countingSink MetricsSink counting SetPoolStats calls
+type countingSink struct {
+	stats int
+}
+
+func (s *countingSink) SetPoolStats(stats sql.DBStats) { s.stats++ }
+func (s *countingSink) ObserveQuery(op Op, table string, duration time.Duration, err error) {
+}
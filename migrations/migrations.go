@@ -0,0 +1,385 @@
+// Package migrations applies numbered .sql migrations of an fs.FS to a mysql.DB.
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "migrations")
+//	m, err := migrations.New(db, sub)
+//	err = m.Up(ctx)
+//
+// Applied versions and checksums of up files are kept in table
+// schema_migrations, changes run under GET_LOCK so concurrent deployments
+// apply each migration once
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+// DefaultTable table of applied versions
+const DefaultTable = "schema_migrations"
+
+var (
+	// ErrLocked another migrator held the lock past the lock timeout
+	ErrLocked = errors.New("migrations: lock timeout")
+	// ErrNoVersion Goto target has no migration
+	ErrNoVersion = errors.New("migrations: no such version")
+	// ErrModified an applied up file changed, Up and Goto refuse to run
+	ErrModified = errors.New("migrations: applied migration modified")
+)
+
+// Migration pair of up and down scripts of a version, Down is empty if the
+// version has no down file
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // hex SHA-256 of Up
+}
+
+// Status state of a migration
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified up file changed since it was applied
+	Modified bool
+}
+
+// applied row of schema_migrations
+type applied struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Option configures Migrator
+type Option func(*Migrator)
+
+// WithTable sets table of applied versions, DefaultTable by default
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLockTimeout sets how long to wait for GET_LOCK, 1 minute by default
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// Migrator applies migrations to db
+type Migrator struct {
+	db          *mysql.DB
+	migrations  []Migration
+	table       string
+	lockTimeout time.Duration
+}
+
+// New returns Migrator of the migration files in the root of fsys. Files are
+// split into statements by mysql.ScriptScanner, DELIMITER lines included
+func New(db *mysql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{
+		db:          db,
+		migrations:  migrations,
+		table:       DefaultTable,
+		lockTimeout: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Load reads <version>_<name>.up.sql and <version>_<name>.down.sql files in
+// the root of fsys sorted by version, other files are ignored
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := entry.Name()
+		var up bool
+		base := strings.TrimSuffix(file, ".up.sql")
+		if base != file {
+			up = true
+		} else if base = strings.TrimSuffix(file, ".down.sql"); base == file {
+			continue
+		}
+		versionText, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(versionText, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migrations: %s: version must be a positive number", file)
+		}
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migrations: version %d named %q and %q", version, migration.Name, name)
+		}
+		script := &migration.Down
+		if up {
+			script = &migration.Up
+		}
+		if *script != "" {
+			return nil, fmt.Errorf("migrations: %s: duplicate file", file)
+		}
+		*script = string(content)
+		if strings.TrimSpace(*script) == "" {
+			return nil, fmt.Errorf("migrations: %s: empty file", file)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migrations: version %d has no up file", migration.Version)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies pending migrations in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(conn *sql.Conn, done map[uint64]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; !ok {
+				if err := m.up(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.run(ctx, func(conn *sql.Conn, done map[uint64]applied) error {
+		for i := len(m.migrations) - 1; i >= 0 && n > 0; i-- {
+			if _, ok := done[m.migrations[i].Version]; ok {
+				if err := m.down(ctx, conn, m.migrations[i]); err != nil {
+					return err
+				}
+				n--
+			}
+		}
+		return nil
+	})
+}
+
+// Goto applies migrations up to version and rolls back those above it,
+// version 0 rolls back every migration
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	if version != 0 {
+		i := sort.Search(len(m.migrations), func(i int) bool {
+			return m.migrations[i].Version >= version
+		})
+		if i == len(m.migrations) || m.migrations[i].Version != version {
+			return fmt.Errorf("%w: %d", ErrNoVersion, version)
+		}
+	}
+	return m.run(ctx, func(conn *sql.Conn, done map[uint64]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].Version > version; i-- {
+			if _, ok := done[m.migrations[i].Version]; ok {
+				if err := m.down(ctx, conn, m.migrations[i]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := done[migration.Version]; !ok {
+				if err := m.up(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status returns state of every migration in version order. It only reads
+// the migrations table, without the lock, and a missing table means no
+// migration is applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.applied(ctx, m.db.Conn)
+	var mysqlErr *MySQL.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1146 { // ER_NO_SUCH_TABLE
+		done, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i].Migration = migration
+		if row, ok := done[migration.Version]; ok {
+			status[i].Applied = true
+			status[i].AppliedAt = row.appliedAt
+			status[i].Modified = row.checksum != migration.Checksum
+		}
+	}
+	return status, nil
+}
+
+// verify checks up files of applied migrations are unchanged
+func (m *Migrator) verify(done map[uint64]applied) error {
+	for _, migration := range m.migrations {
+		if row, ok := done[migration.Version]; ok && row.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrModified, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// lockName names the migration lock of a database and table, GET_LOCK
+// accepts at most 64 characters (error 3057) so it is their SHA1 digest
+const lockName = "SHA1(CONCAT(DATABASE(), '.migrations.', ?))"
+
+// run calls fn holding the migration lock on a dedicated connection with
+// applied versions of the migrations table
+func (m *Migrator) run(ctx context.Context, fn func(conn *sql.Conn, done map[uint64]applied) error) (err error) {
+	conn, err := m.db.Conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK("+lockName+", ?)", m.table, int64(m.lockTimeout/time.Second)).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLocked
+	}
+	defer func() {
+		// lock is released with the connection if RELEASE_LOCK fails
+		if _, releaseErr := conn.ExecContext(context.Background(), "DO RELEASE_LOCK("+lockName+")", m.table); releaseErr != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+mysql.EscapeID(m.table, true)+` (
+		version BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at DATETIME(6) NOT NULL
+	)`); err != nil {
+		return err
+	}
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, done)
+}
+
+// queryer connection or pool the migrations table is read from
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied reads the migrations table
+func (m *Migrator) applied(ctx context.Context, conn queryer) (map[uint64]applied, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, DATE_FORMAT(applied_at, '%Y-%m-%d %H:%i:%s.%f') FROM "+mysql.EscapeID(m.table, true))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	done := make(map[uint64]applied)
+	for rows.Next() {
+		var version uint64
+		var row applied
+		var appliedAt string
+		if err := rows.Scan(&version, &row.checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		if row.appliedAt, err = time.ParseInLocation("2006-01-02 15:04:05.000000", appliedAt, time.UTC); err != nil {
+			return nil, err
+		}
+		done[version] = row
+	}
+	return done, rows.Err()
+}
+
+// execScript runs statements of script on conn
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	scanner := mysql.NewScriptScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		if _, err := conn.ExecContext(ctx, stmt.SQL); err != nil {
+			return &mysql.ScriptError{ScriptStatement: stmt, Err: err}
+		}
+	}
+	return scanner.Err()
+}
+
+// up runs the up script of migration and records it
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if err := execScript(ctx, conn, migration.Up); err != nil {
+		return fmt.Errorf("migrations: %d_%s up: %w", migration.Version, migration.Name, err)
+	}
+	_, err := conn.ExecContext(ctx, "INSERT INTO "+mysql.EscapeID(m.table, true)+" (version, name, checksum, applied_at) VALUES (?, ?, ?, UTC_TIMESTAMP(6))",
+		migration.Version, migration.Name, migration.Checksum)
+	return err
+}
+
+// down runs the down script of migration and removes its record
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migrations: %d_%s has no down file", migration.Version, migration.Name)
+	}
+	if err := execScript(ctx, conn, migration.Down); err != nil {
+		return fmt.Errorf("migrations: %d_%s down: %w", migration.Version, migration.Name, err)
+	}
+	_, err := conn.ExecContext(ctx, "DELETE FROM "+mysql.EscapeID(m.table, true)+" WHERE version = ?", migration.Version)
+	return err
+}
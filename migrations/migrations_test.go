@@ -0,0 +1,220 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/mysqltest"
+	"github.com/vinhjaxt/mysql-go/mysqltest/mysqlserver"
+)
+
+var files = fstest.MapFS{
+	"1_users.up.sql":     {Data: []byte("create table users;\n-- seed\ninsert into users values (1);\n")},
+	"1_users.down.sql":   {Data: []byte("drop table users")},
+	"2_posts.up.sql":     {Data: []byte("create table posts")},
+	"2_posts.down.sql":   {Data: []byte("drop table posts")},
+	"10_tags.up.sql":     {Data: []byte("create table tags")},
+	"10_tags.down.sql":   {Data: []byte("drop table tags")},
+	"README.md":          {Data: []byte("not a migration")},
+	"seed/1_data.up.sql": {Data: []byte("ignored")},
+}
+
+const selectApplied = "SELECT version, checksum, DATE_FORMAT(applied_at, '%Y-%m-%d %H:%i:%s.%f') FROM `schema_migrations`"
+
+// appliedRows returns rows of the migrations table of versions: checksum
+func appliedRows(versions map[uint64]string) *mysqltest.Rows {
+	rows := mysqltest.NewRows("version", "checksum", "applied_at")
+	for version, checksum := range versions {
+		rows.AddRow(version, checksum, "2024-01-02 03:04:05.000000")
+	}
+	return rows
+}
+
+// expectLock expects run to take the lock and read versions
+func expectLock(db *mysqltest.Fake, versions map[uint64]string) {
+	db.ExpectQuery("SELECT GET_LOCK(SHA1(CONCAT(DATABASE(), '.migrations.', ?)), ?)").WithArgs("schema_migrations", 60).
+		WillReturnRows(mysqltest.NewRows("locked").AddRow(1))
+	db.ExpectExec("^CREATE TABLE IF NOT EXISTS `schema_migrations` ").MatchRegexp()
+	db.ExpectQuery(selectApplied).WillReturnRows(appliedRows(versions))
+}
+
+// expectUnlock expects run to release the lock
+func expectUnlock(db *mysqltest.Fake) {
+	db.ExpectExec("DO RELEASE_LOCK(SHA1(CONCAT(DATABASE(), '.migrations.', ?)))").WithArgs("schema_migrations")
+}
+
+// expectUp expects statements of the up script of migration and its record
+func expectUp(db *mysqltest.Fake, migration Migration, statements ...string) {
+	for _, statement := range statements {
+		db.ExpectExec(statement)
+	}
+	db.ExpectExec("INSERT INTO `schema_migrations` (version, name, checksum, applied_at) VALUES (?, ?, ?, UTC_TIMESTAMP(6))").
+		WithArgs(migration.Version, migration.Name, migration.Checksum)
+}
+
+// expectDown expects the down script of migration and removal of its record
+func expectDown(db *mysqltest.Fake, migration Migration) {
+	db.ExpectExec(migration.Down)
+	db.ExpectExec("DELETE FROM `schema_migrations` WHERE version = ?").WithArgs(migration.Version)
+}
+
+func newMigrator(t *testing.T, db *mysqltest.Fake, fsys fstest.MapFS) *Migrator {
+	m, err := New(db.DB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []uint64
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	if want := []uint64{1, 2, 10}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("versions = %v, want %v", versions, want)
+	}
+	if migrations[0].Name != "users" || migrations[0].Down != "drop table users" || len(migrations[0].Checksum) != 64 {
+		t.Errorf("migration 1 = %+v", migrations[0])
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no up":       {"1_a.down.sql": {Data: []byte("x")}},
+		"bad version": {"a_b.up.sql": {Data: []byte("x")}},
+		"renamed":     {"1_a.up.sql": {Data: []byte("x")}, "1_b.down.sql": {Data: []byte("x")}},
+		"empty":       {"1_a.up.sql": {Data: []byte(" \n")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db := mysqltest.NewFake(t)
+	m := newMigrator(t, db, files)
+	ctx := context.Background()
+	users, posts, tags := m.migrations[0], m.migrations[1], m.migrations[2]
+
+	expectLock(db, nil)
+	expectUp(db, users, "create table users", "insert into users values (1)")
+	expectUp(db, posts, "create table posts")
+	expectUnlock(db)
+	if err := m.Goto(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	expectLock(db, map[uint64]string{1: users.Checksum, 2: posts.Checksum})
+	expectUp(db, tags, "create table tags")
+	expectUnlock(db)
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expectLock(db, map[uint64]string{1: users.Checksum, 2: posts.Checksum, 10: tags.Checksum})
+	expectDown(db, tags)
+	expectDown(db, posts)
+	expectUnlock(db)
+	if err := m.Down(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Goto(ctx, 3); !errors.Is(err, ErrNoVersion) {
+		t.Errorf("Goto(3) = %v, want ErrNoVersion", err)
+	}
+
+	expectLock(db, map[uint64]string{1: "changed"})
+	expectUnlock(db)
+	if err := m.Up(ctx); !errors.Is(err, ErrModified) {
+		t.Errorf("Up with modified migration = %v, want ErrModified", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	db := mysqltest.NewFake(t)
+	m := newMigrator(t, db, files)
+	ctx := context.Background()
+
+	// read only: no lock, no CREATE TABLE
+	db.ExpectQuery(selectApplied).WillReturnRows(appliedRows(map[uint64]string{1: m.migrations[0].Checksum, 2: "changed"}))
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 3 || !status[0].Applied || status[0].Modified || status[0].AppliedAt.Year() != 2024 || !status[1].Modified || status[2].Applied {
+		t.Errorf("status = %+v", status)
+	}
+
+	db.ExpectQuery(selectApplied).WillReturnError(&MySQL.MySQLError{Number: 1146, Message: "Table 'test.schema_migrations' doesn't exist"})
+	status, err = m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 3 || status[0].Applied || status[1].Applied || status[2].Applied {
+		t.Errorf("status without table = %+v", status)
+	}
+}
+
+func TestMigratorError(t *testing.T) {
+	db := mysqltest.NewFake(t)
+	m := newMigrator(t, db, fstest.MapFS{
+		"1_a.up.sql": {Data: []byte("create table a")},
+		"2_b.up.sql": {Data: []byte("select 1;\n\nfail")},
+	})
+	a := m.migrations[0]
+
+	expectLock(db, nil)
+	expectUp(db, a, "create table a")
+	db.ExpectExec("select 1")
+	db.ExpectExec("fail").WillReturnError(errors.New("syntax error"))
+	expectUnlock(db)
+	err := m.Up(context.Background())
+	var scriptErr *mysql.ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 3 || !strings.Contains(err.Error(), "2_b up") {
+		t.Errorf("Up = %v, want 2_b up error at line 3", err)
+	}
+
+	expectLock(db, map[uint64]string{1: a.Checksum})
+	expectUnlock(db)
+	if err := m.Down(context.Background(), 1); err == nil {
+		t.Error("Down without down file succeeded")
+	}
+}
+
+func TestLockName(t *testing.T) {
+	db := mysqlserver.NewServer(t)
+	table := strings.Repeat("m", 64)
+	name, err := db.Single("SELECT "+lockName, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GET_LOCK fails with error 3057 on names over 64 characters
+	if len(name.String) != 40 {
+		t.Errorf("lock name %q of %d characters, want a SHA1 digest", name.String, len(name.String))
+	}
+
+	m, err := New(db, fstest.MapFS{"1_a.up.sql": {Data: []byte("create table a (id int primary key)")}}, WithTable(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || !status[0].Applied {
+		t.Errorf("status = %+v", status)
+	}
+}
@@ -3,13 +3,16 @@ package mysql
 // https://github.com/GoogleCloudPlatform/golang-samples/blob/master/getting-started/bookshelf/db_mysql.go
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	MySQL "github.com/go-sql-driver/mysql"
@@ -17,7 +20,27 @@ import (
 
 // DB contains mysql connection and function
 type DB struct {
-	Conn *sql.DB
+	Conn  *sql.DB
+	stmts *stmtCache // nil when disabled
+	esc   escaper    // matches server sql_mode and config.Loc
+
+	dbName string // config.DBName, schema of introspection
+
+	maxInListSize int // values per IN list, see WithMaxInListSize
+
+	jsonObjects bool // store nested maps, structs and slices as JSON
+	jsonCast    bool
+
+	interceptors []Interceptor
+	tx           *sql.Tx         // set on DB of a Tx
+	conn         *sql.Conn       // see WithConn
+	ctx          context.Context // see WithContext
+}
+
+// queryer runs statements, *sql.DB or *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 // Config fast config
@@ -72,11 +95,10 @@ func createDatabaseSchema(config *MySQL.Config, conn *sql.DB) error {
 // NewConfig create new mysql config from fast config
 func NewConfig(config *Config) *MySQL.Config {
 	mysqlConfig := MySQL.NewConfig()
+	// the handshake sets the collation and so the utf8mb4 charset, a charset
+	// in Params would be sent as an unknown SET charset=... system variable
 	mysqlConfig.Collation = "utf8mb4_unicode_ci"
 	mysqlConfig.MultiStatements = true
-	mysqlConfig.Params = map[string]string{
-		"charset": "utf8mb4,utf8",
-	}
 	mysqlConfig.User = config.User
 	mysqlConfig.Passwd = config.Passwd
 	if len(config.UnixSocket) > 0 {
@@ -94,15 +116,27 @@ func NewConfig(config *Config) *MySQL.Config {
 }
 
 // New create new mysql connection
-func New(config *MySQL.Config) (*DB, error) {
-	// Check database schema exists. If not, create it.
-	if err := ensureDatabaseSchema(config); err != nil {
-		return nil, err
+func New(config *MySQL.Config, opts ...Option) (*DB, error) {
+	o := newOptions(opts)
+	if o.interpolateParams {
+		config = config.Clone()
+		config.InterpolateParams = true
 	}
 
-	conn, err := sql.Open("mysql", config.FormatDSN())
-	if err != nil {
-		return nil, fmt.Errorf("mysql: could not get a connection: %v", err)
+	var conn *sql.DB
+	if o.connector != nil {
+		conn = sql.OpenDB(o.connector)
+	} else {
+		// Check database schema exists. If not, create it.
+		if err := ensureDatabaseSchema(config); err != nil {
+			return nil, err
+		}
+
+		var err error
+		conn, err = sql.Open("mysql", config.FormatDSN())
+		if err != nil {
+			return nil, fmt.Errorf("mysql: could not get a connection: %v", err)
+		}
 	}
 	if err := conn.Ping(); err != nil {
 		conn.Close()
@@ -112,29 +146,160 @@ func New(config *MySQL.Config) (*DB, error) {
 	maxConnectionCount := runtime.NumCPU() * 2
 	conn.SetMaxIdleConns(maxConnectionCount)
 	conn.SetMaxOpenConns(maxConnectionCount)
-	return &DB{
-		Conn: conn,
-	}, nil
+	db := &DB{
+		Conn:   conn,
+		dbName: config.DBName,
+		esc: escaper{
+			loc: config.Loc,
+		},
+		jsonObjects:   o.jsonObjects,
+		jsonCast:      o.jsonCast,
+		interceptors:  o.interceptors,
+		maxInListSize: o.maxInListSize,
+	}
+	if !o.interpolateParams && o.stmtCacheSize > 0 {
+		db.stmts = newStmtCache(o.stmtCacheSize)
+	}
+
+	// NO_BACKSLASH_ESCAPES makes backslash an ordinary character in string literals
+	var sqlMode string
+	if err := conn.QueryRow("SELECT @@SESSION.sql_mode").Scan(&sqlMode); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mysql: could not read sql_mode: %v", err)
+	}
+	db.esc.noBackslashEscapes = hasSQLMode(sqlMode, "NO_BACKSLASH_ESCAPES")
+	return db, nil
+}
+
+func hasSQLMode(sqlMode, mode string) bool {
+	for _, m := range strings.Split(sqlMode, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), mode) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes cached statements and the connection pool
+func (db *DB) Close() error {
+	if db.stmts != nil {
+		db.stmts.close()
+	}
+	return db.Conn.Close()
+}
+
+// WithContext returns a copy of db running statements with ctx
+func (db *DB) WithContext(ctx context.Context) *DB {
+	clone := *db
+	clone.ctx = ctx
+	return &clone
+}
+
+// WithConn returns a copy of db running statements and transactions on conn,
+// so session variables carry over between them. Statements bypass the
+// statement cache
+func (db *DB) WithConn(conn *sql.Conn) *DB {
+	clone := *db
+	clone.conn = conn
+	return &clone
+}
+
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
+}
+
+// queryer returns transaction of db, its dedicated connection or the connection pool
+func (db *DB) queryer() queryer {
+	if db.tx != nil {
+		return db.tx
+	}
+	if db.conn != nil {
+		return db.conn
+	}
+	return db.Conn
+}
+
+// query runs select through interceptors, c.after must be called when rows are read
+func (db *DB) query(op Op, sqlQuery string, args []interface{}) (*sql.Rows, *call, error) {
+	sqlQuery, args, err := expandArgs(sqlQuery, args, db.maxInListSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := db.before(op, "", sqlQuery, args)
+	rows, err := db.queryer().QueryContext(c.ctx, c.info.SQL, c.info.Args...)
+	if err != nil {
+		c.after(nil, 0, err)
+		return nil, nil, err
+	}
+	return rows, c, nil
+}
+
+// exec runs statement through interceptors
+func (db *DB) exec(op Op, table, sqlQuery string, args ...interface{}) (sql.Result, error) {
+	c := db.before(op, table, sqlQuery, args)
+	res, err := db.execContext(c.ctx, c.info.SQL, c.info.Args)
+	c.after(res, 0, err)
+	return res, err
+}
+
+// execContext runs statement with args through statement cache if enabled
+func (db *DB) execContext(ctx context.Context, sqlQuery string, args []interface{}) (sql.Result, error) {
+	if db.stmts == nil || len(args) == 0 || db.conn != nil {
+		return db.queryer().ExecContext(ctx, sqlQuery, args...)
+	}
+	stmt, release, err := db.stmts.prepare(db.Conn, sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if db.tx != nil {
+		stmt = db.tx.StmtContext(ctx, stmt)
+		defer stmt.Close()
+	}
+	return stmt.ExecContext(ctx, args...)
 }
 
 // Single select one column in one rows
 // return sql.ErrNoRows if no row found
-func (db *DB) Single(sqlQuery string, values ...interface{}) (*sql.NullString, error) {
-	data := new(sql.NullString)
-	row := db.Conn.QueryRow(sqlQuery, values...)
-	err := row.Scan(data)
+func (db *DB) Single(sqlQuery string, values ...interface{}) (data *sql.NullString, err error) {
+	rows, c, err := db.query(OpSingle, sqlQuery, values)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { c.after(nil, rowCount(data != nil), err) }()
+	defer rows.Close()
+
+	if rows.Next() == false {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	data = new(sql.NullString)
+	err = rows.Scan(data)
 	if err != nil {
 		return nil, err
 	}
 	return data, nil
 }
 
+func rowCount(found bool) int64 {
+	if found {
+		return 1
+	}
+	return 0
+}
+
 // Row select one row in table
-func (db *DB) Row(sqlQuery string, args ...interface{}) (map[string]*sql.NullString, error) {
-	row, err := db.Conn.Query(sqlQuery, args...)
+func (db *DB) Row(sqlQuery string, args ...interface{}) (res map[string]*sql.NullString, err error) {
+	row, c, err := db.query(OpRow, sqlQuery, args)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { c.after(nil, rowCount(res != nil), err) }()
 	defer row.Close()
 
 	if row.Next() == false {
@@ -152,7 +317,7 @@ func (db *DB) Row(sqlQuery string, args ...interface{}) (map[string]*sql.NullStr
 	// references into such a slice
 	// See http://code.google.com/p/go-wiki/wiki/InterfaceSlice for details
 	scanArgs := make([]interface{}, len(values))
-	res := make(map[string]*sql.NullString)
+	res = make(map[string]*sql.NullString)
 	for i := range values {
 		value := &values[i]
 		scanArgs[i] = value
@@ -167,11 +332,12 @@ func (db *DB) Row(sqlQuery string, args ...interface{}) (map[string]*sql.NullStr
 }
 
 // Rows select rows in table
-func (db *DB) Rows(sqlQuery string, args ...interface{}) ([]map[string]*sql.NullString, error) {
-	rows, err := db.Conn.Query(sqlQuery, args...)
+func (db *DB) Rows(sqlQuery string, args ...interface{}) (ret []map[string]*sql.NullString, err error) {
+	rows, c, err := db.query(OpRows, sqlQuery, args)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { c.after(nil, int64(len(ret)), err) }()
 	defer rows.Close()
 
 	if rows.Next() == false {
@@ -184,7 +350,6 @@ func (db *DB) Rows(sqlQuery string, args ...interface{}) ([]map[string]*sql.Null
 	if err != nil {
 		return nil, err
 	}
-	var ret []map[string]*sql.NullString
 
 	for {
 		values := make([]sql.NullString, len(columns))
@@ -211,15 +376,24 @@ func (db *DB) Rows(sqlQuery string, args ...interface{}) ([]map[string]*sql.Null
 	return ret, nil
 }
 
+// setRowCount number of rows in all sets
+func setRowCount(sets [][]map[string]*sql.NullString) int64 {
+	var n int64
+	for _, set := range sets {
+		n += int64(len(set))
+	}
+	return n
+}
+
 // SetRows select rows of each result sets excludes nil set
-func (db *DB) SetRows(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error) {
-	rows, err := db.Conn.Query(sqlQuery, args...)
+func (db *DB) SetRows(sqlQuery string, args ...interface{}) (ret [][]map[string]*sql.NullString, err error) {
+	rows, c, err := db.query(OpSetRows, sqlQuery, args)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { c.after(nil, setRowCount(ret), err) }()
 	defer rows.Close()
 
-	var ret [][]map[string]*sql.NullString
 	var columns []string
 
 	for {
@@ -268,14 +442,14 @@ func (db *DB) SetRows(sqlQuery string, args ...interface{}) ([][]map[string]*sql
 }
 
 // SetRowsNil select rows of each result sets includes nil set
-func (db *DB) SetRowsNil(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error) {
-	rows, err := db.Conn.Query(sqlQuery, args...)
+func (db *DB) SetRowsNil(sqlQuery string, args ...interface{}) (ret [][]map[string]*sql.NullString, err error) {
+	rows, c, err := db.query(OpSetRowsNil, sqlQuery, args)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { c.after(nil, setRowCount(ret), err) }()
 	defer rows.Close()
 
-	var ret [][]map[string]*sql.NullString
 	var columns []string
 
 	for {
@@ -323,14 +497,71 @@ func (db *DB) SetRowsNil(sqlQuery string, args ...interface{}) ([][]map[string]*
 	return ret, nil
 }
 
+// bufferPool reuses query buffers of bulk inserts
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new([]byte)
+	},
+}
+
+// maxPooledBuffer larger buffers are left to the garbage collector
+const maxPooledBuffer = 4 << 20
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// appendInsert appends insert statement of data rows to buf, Sensitive
+// values are bound to placeholders and returned as args
+func (db *DB) appendInsert(buf []byte, table string, columns []string, data []interface{}) ([]byte, []interface{}, error) {
+	buf = append(buf, "insert "...)
+	buf = AppendEscapeID(buf, table, false)
+	buf = append(buf, " ("...)
+	buf = AppendEscapeIDs(buf, columns, true)
+	buf = append(buf, ") values "...)
+	if db.jsonObjects {
+		data = db.jsonRows(data)
+	}
+	var args []interface{}
+	esc := db.esc
+	esc.bound = &args
+	buf, err := esc.appendEscape(buf, data, false)
+	return buf, args, err
+}
+
+// jsonRows wraps maps, structs and slices in rows with JSON
+func (db *DB) jsonRows(data []interface{}) []interface{} {
+	rows := make([]interface{}, len(data))
+	for i, row := range data {
+		rv := reflect.ValueOf(row)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			rows[i] = row
+			continue
+		}
+		values := make([]interface{}, rv.Len())
+		for j := range values {
+			values[j] = rv.Index(j).Interface()
+			if isJSONObject(values[j]) {
+				values[j] = JSONValue{V: values[j], Cast: db.jsonCast}
+			}
+		}
+		rows[i] = values
+	}
+	return rows
+}
+
 // Insert into table
 func (db *DB) Insert(table string, columns []string, data []interface{}) (insertID int64, err error) {
-	escapedData, err := Escape(data, false)
+	bufp := bufferPool.Get().(*[]byte)
+	defer putBuffer(bufp)
+	buf, args, err := db.appendInsert((*bufp)[:0], table, columns, data)
 	if err != nil {
 		return
 	}
-	sqlQuery := "insert " + EscapeID(table, false) + " (" + EscapeIDs(columns, true) + ") values " + escapedData
-	res, err := db.Conn.Exec(sqlQuery)
+	*bufp = buf
+	res, err := db.exec(OpInsert, table, string(buf), args...)
 	if err != nil {
 		return
 	}
@@ -339,43 +570,54 @@ func (db *DB) Insert(table string, columns []string, data []interface{}) (insert
 
 // InsertUpdate into table and update if existed
 func (db *DB) InsertUpdate(table string, columns []string, data []interface{}) (sql.Result, error) {
-	escapedData, err := Escape(data, false)
+	bufp := bufferPool.Get().(*[]byte)
+	defer putBuffer(bufp)
+	buf, args, err := db.appendInsert((*bufp)[:0], table, columns, data)
 	if err != nil {
 		return nil, err
 	}
-	colStr := ""
-	updateStr := ""
+	buf = append(buf, " ON DUPLICATE KEY UPDATE "...)
 	for i, val := range columns {
 		if i != 0 {
-			colStr += ", "
-			updateStr += ", "
+			buf = append(buf, ", "...)
 		}
-		escaped := EscapeID(val, true)
-		colStr += escaped
-		updateStr += escaped + "=values(" + escaped + ")"
+		buf = AppendEscapeID(buf, val, true)
+		buf = append(buf, "=values("...)
+		buf = AppendEscapeID(buf, val, true)
+		buf = append(buf, ')')
 	}
-	sqlQuery := "insert " + EscapeID(table, false) + " (" + colStr + ") values " + escapedData + " ON DUPLICATE KEY UPDATE " + updateStr
+	*bufp = buf
 
-	return db.Conn.Exec(sqlQuery)
+	return db.exec(OpInsertUpdate, table, string(buf), args...)
 }
 
 // Update row(s) in table
 func (db *DB) Update(table string, data interface{}, where interface{}, limits ...uint64) (affectedRows int64, err error) {
-	fields, values, err := BuildFieldValue(data, "=?")
+	fields, values, err := buildFieldValue(data, "=?", true)
 	if err != nil {
 		return 0, err
 	}
 	if len(fields) == 0 {
 		return 0, errors.New("mysql.update: data is empty")
 	}
+	if db.jsonObjects {
+		for i, value := range values {
+			if isJSONObject(value) {
+				values[i] = JSON(value)
+				if db.jsonCast {
+					fields[i] = strings.TrimSuffix(fields[i], "=?") + "=CAST(? AS JSON)"
+				}
+			}
+		}
+	}
 	sqlQuery := "update " + EscapeID(table, true) + " set " + strings.Join(fields, ",")
 
-	fields, whereValues, err := BuildFieldValue(where, "=?")
+	whereSQL, whereValues, err := db.buildWhere(where)
 	if err != nil {
 		return 0, err
 	}
-	if len(fields) != 0 {
-		sqlQuery += " where " + strings.Join(fields, " and ")
+	if whereSQL != "" {
+		sqlQuery += " where " + whereSQL
 		values = append(values, whereValues...)
 	}
 
@@ -383,51 +625,81 @@ func (db *DB) Update(table string, data interface{}, where interface{}, limits .
 		sqlQuery += " limit " + strconv.FormatUint(limits[0], 10)
 	}
 
-	stmt, err := db.Conn.Prepare(sqlQuery)
-	if err != nil {
-		return
-	}
-	defer stmt.Close()
-	res, err := stmt.Exec(values...)
+	res, err := db.exec(OpUpdate, table, sqlQuery, values...)
 	if err != nil {
 		return
 	}
 	return res.RowsAffected()
 }
 
+// buildWhere build where condition and values from struct, map or Condition,
+// slice values of structs and maps match with IN, slice args expand like in Query
+func (db *DB) buildWhere(where interface{}) (string, []interface{}, error) {
+	var fields []string
+	var values []interface{}
+	switch cond := where.(type) {
+	case Condition:
+		fields, values = []string{"(" + cond.SQL + ")"}, cond.Args
+	case *Condition:
+		fields, values = []string{"(" + cond.SQL + ")"}, cond.Args
+	default:
+		var err error
+		if fields, values, err = BuildFieldValue(where, "=?"); err != nil {
+			return "", nil, err
+		}
+		for i, value := range values {
+			if _, ok := expandable(value); ok {
+				fields[i] = strings.TrimSuffix(fields[i], "=?") + " IN (?)"
+			}
+		}
+	}
+	return expandArgs(strings.Join(fields, " and "), values, db.maxInListSize)
+}
+
 // Delete row(s) in table
 func (db *DB) Delete(table string, where interface{}, limits ...uint64) (affectedRows int64, err error) {
-	fields, values, err := BuildFieldValue(where, "=?")
+	whereSQL, values, err := db.buildWhere(where)
 	if err != nil {
 		return 0, err
 	}
-	if len(fields) == 0 {
+	if whereSQL == "" {
 		return 0, errors.New("mysql.delete: data is empty")
 	}
-	sqlQuery := "delete from " + EscapeID(table, false) + " where " + strings.Join(fields, " and ")
+	sqlQuery := "delete from " + EscapeID(table, false) + " where " + whereSQL
 
 	if len(limits) > 0 {
 		sqlQuery += " limit " + strconv.FormatUint(limits[0], 10)
 	}
 
-	stmt, err := db.Conn.Prepare(sqlQuery)
-	if err != nil {
-		return
-	}
-	defer stmt.Close()
-	res, err := stmt.Exec(values...)
+	res, err := db.exec(OpDelete, table, sqlQuery, values...)
 	if err != nil {
 		return
 	}
 	return res.RowsAffected()
 }
 
+// Escape escapes mysql value using quote doubling when the server
+// runs with NO_BACKSLASH_ESCAPES, backslashes otherwise.
+// time.Time is converted to config.Loc
+func (db *DB) Escape(val interface{}, stringifyObjects bool) (string, error) {
+	return db.esc.escape(val, stringifyObjects)
+}
+
+// AppendEscape appends escaped mysql value to buf like db.Escape
+func (db *DB) AppendEscape(buf []byte, val interface{}) ([]byte, error) {
+	return db.esc.appendEscape(buf, val, false)
+}
+
+// EscapeID escapes mysql field
+func (db *DB) EscapeID(val string, forbidQualified bool) string {
+	return EscapeID(val, forbidQualified)
+}
+
 // Query a sql query
 func (db *DB) Query(sql string, values ...interface{}) (sql.Result, error) {
-	stmt, err := db.Conn.Prepare(sql)
+	sql, values, err := expandArgs(sql, values, db.maxInListSize)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-	return stmt.Exec(values...)
+	return db.exec(OpQuery, "", sql, values...)
 }
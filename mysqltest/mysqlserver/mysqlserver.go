@@ -0,0 +1,56 @@
+// Package mysqlserver runs an in-memory MySQL protocol server
+// (github.com/dolthub/go-mysql-server, v0.20.0 or later) for tests of SQL
+// behavior without an external MySQL. It is apart from package mysqltest so
+// that unit tests using mysqltest.Fake do not depend on the SQL engine.
+//
+// go-mysql-server implements most, not all, of MySQL: multi statement
+// queries, DELETE ... LIMIT and LAST_INSERT_ID of multi row inserts behave
+// like MySQL, engine specific features such as full text indexes do not.
+package mysqlserver
+
+import (
+	"net"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+// Database database of the NewServer DB
+const Database = "test"
+
+// NewServer starts an in-memory MySQL protocol server on a random local port
+// and returns a DB of its Database connected through mysql.NewConfig and
+// mysql.New with opts. Data lives as long as the server, both stop at the
+// end of t
+func NewServer(t testing.TB, opts ...mysql.Option) *mysql.DB {
+	t.Helper()
+	provider := memory.NewDBProvider(memory.NewDatabase(Database))
+	engine := sqle.NewDefault(provider)
+	config := server.Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+	}
+	s, err := server.NewServer(config, engine, gms.NewContext, memory.NewSessionBuilder(provider), nil)
+	if err != nil {
+		t.Fatalf("mysqlserver: start server: %v", err)
+	}
+	go s.Start()
+	t.Cleanup(func() { s.Close() })
+
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	db, err := mysql.New(mysql.NewConfig(&mysql.Config{
+		User:   "root",
+		Host:   addr.IP.String(),
+		Port:   addr.Port,
+		DBName: Database,
+	}), opts...)
+	if err != nil {
+		t.Fatalf("mysqlserver: connect server: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
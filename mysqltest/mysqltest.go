@@ -0,0 +1,385 @@
+// Package mysqltest provides Fake, a scripted database for unit tests of code
+// taking a mysql.Querier. Package mysqltest/mysqlserver runs an in-memory
+// MySQL server for tests of SQL behavior.
+//
+// Fake is a mysql.DB on a scripted driver: the helpers build the same SQL as
+// against a server, Fake records it and answers with the rows, results and
+// errors of the expectations, which must come in order:
+//
+//	db := mysqltest.NewFake(t)
+//	db.ExpectQuery("select name from users where id = ?").WithArgs(1).
+//		WillReturnRows(mysqltest.NewRows("name").AddRow("alice"))
+//	db.ExpectExec("delete from users where id = ?").WillReturnResult(0, 1)
+//	svc := NewService(db) // takes a mysql.Querier
+//
+// Statements match the expected SQL exactly, except that whitespace runs
+// compare as one space; MatchDigest compares mysql.Normalize digests instead
+// and MatchRegexp takes the SQL as a regular expression. Bound values are
+// checked with WithArgs, and the received SQL is listed by Calls. Unmet
+// expectations fail the test at its end.
+package mysqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/internal/driverutil"
+)
+
+// Call statement received by Fake
+type Call struct {
+	SQL  string
+	Args []interface{}
+}
+
+// kind of statement
+type kind string
+
+const (
+	kindQuery    kind = "query"
+	kindExec     kind = "exec"
+	kindBegin    kind = "begin"
+	kindCommit   kind = "commit"
+	kindRollback kind = "rollback"
+)
+
+// Expectation expected statement and its answer
+type Expectation struct {
+	kind      kind
+	sql       string
+	match     string                // how sql is compared, for String
+	matches   func(sql string) bool // nil compares sql as is
+	args      []interface{}
+	checkArgs bool
+	rows      []*Rows
+	result    driver.Result
+	err       error
+}
+
+// MatchDigest matches statements of the same mysql.Normalize digest, so case,
+// spacing, identifier quotes and literal values are ignored
+func (e *Expectation) MatchDigest() *Expectation {
+	digest := mysql.Normalize(e.sql)
+	e.match = "digest of "
+	e.matches = func(sql string) bool { return mysql.Normalize(sql) == digest }
+	return e
+}
+
+// MatchRegexp matches statements containing a match of the SQL taken as a
+// regular expression, it panics if the expression does not compile
+func (e *Expectation) MatchRegexp() *Expectation {
+	re := regexp.MustCompile(e.sql)
+	e.match = "regexp "
+	e.matches = re.MatchString
+	return e
+}
+
+// WithArgs expects the statement args, compared after driver conversion
+// (int is int64, uint is uint64, float32 is float64)
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.checkArgs = true
+	e.args = make([]interface{}, len(args))
+	for i, arg := range args {
+		e.args[i] = mustConvert(arg)
+	}
+	return e
+}
+
+// WillReturnRows answers a query with result sets rows
+func (e *Expectation) WillReturnRows(rows ...*Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnResult answers an exec with last insert id and affected rows
+func (e *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	e.result = driverutil.Result{InsertID: lastInsertID, Affected: rowsAffected}
+	return e
+}
+
+// WillReturnError fails the statement with err
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.sql == "" {
+		return string(e.kind)
+	}
+	if e.checkArgs {
+		return fmt.Sprintf("%s %s%q with args %v", e.kind, e.match, e.sql, e.args)
+	}
+	return fmt.Sprintf("%s %s%q", e.kind, e.match, e.sql)
+}
+
+// Rows result set of a query
+type Rows struct {
+	set driverutil.ResultSet
+}
+
+// NewRows returns an empty result set of columns
+func NewRows(columns ...string) *Rows {
+	return &Rows{set: driverutil.ResultSet{Columns: columns}}
+}
+
+// AddRow appends a row, values are converted like statement args
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	if len(values) != len(r.set.Columns) {
+		panic(fmt.Sprintf("mysqltest: row of %d values for %d columns", len(values), len(r.set.Columns)))
+	}
+	row := make([]driver.Value, len(values))
+	for i, v := range values {
+		row[i] = mustConvert(v)
+	}
+	r.set.Rows = append(r.set.Rows, row)
+	return r
+}
+
+// mustConvert converts v to a driver.Value like statement args, panics on
+// unsupported types
+func mustConvert(v interface{}) driver.Value {
+	nv := driver.NamedValue{Value: v}
+	if driverutil.CheckNamedValue(&nv) == nil {
+		return nv.Value
+	}
+	value, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		panic("mysqltest: " + err.Error())
+	}
+	return value
+}
+
+// Fake mysql.DB answering statements with expectations
+type Fake struct {
+	*mysql.DB
+
+	mu       sync.Mutex
+	expected []*Expectation
+	next     int // index of the next expectation
+	calls    []Call
+	failures []string
+}
+
+// NewFake returns a Fake failing t at its end unless every expectation is met
+func NewFake(t testing.TB) *Fake {
+	t.Helper()
+	f := &Fake{}
+	f.DB = &mysql.DB{Conn: sql.OpenDB(connector{f})}
+	t.Cleanup(func() {
+		f.Close()
+		if err := f.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return f
+}
+
+// ExpectQuery expects a select of sql
+func (f *Fake) ExpectQuery(sql string) *Expectation {
+	return f.expect(&Expectation{kind: kindQuery, sql: collapse(sql)})
+}
+
+// ExpectExec expects a statement of sql, it answers with no rows affected
+// unless WillReturnResult is given
+func (f *Fake) ExpectExec(sql string) *Expectation {
+	return f.expect(&Expectation{kind: kindExec, sql: collapse(sql)})
+}
+
+// ExpectBegin expects a transaction start
+func (f *Fake) ExpectBegin() *Expectation {
+	return f.expect(&Expectation{kind: kindBegin})
+}
+
+// ExpectCommit expects a transaction commit
+func (f *Fake) ExpectCommit() *Expectation {
+	return f.expect(&Expectation{kind: kindCommit})
+}
+
+// ExpectRollback expects a transaction rollback
+func (f *Fake) ExpectRollback() *Expectation {
+	return f.expect(&Expectation{kind: kindRollback})
+}
+
+func (f *Fake) expect(e *Expectation) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expected = append(f.expected, e)
+	return e
+}
+
+// Connector returns the connector of f's connections, to open f through a
+// wrapping connector such as replay.NewRecorder
+func (f *Fake) Connector() driver.Connector {
+	return connector{f}
+}
+
+// Calls returns statements received so far, expected or not
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// ExpectationsWereMet returns an error listing unexpected statements and
+// expectations not reached
+func (f *Fake) ExpectationsWereMet() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	problems := append([]string(nil), f.failures...)
+	for _, e := range f.expected[f.next:] {
+		problems = append(problems, "not received: "+e.String())
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("mysqltest: " + strings.Join(problems, "; "))
+}
+
+// match consumes the next expectation if it is k of sql with args
+func (f *Fake) match(k kind, sql string, args []driver.NamedValue) (*Expectation, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	sql = collapse(sql)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if sql != "" {
+		f.calls = append(f.calls, Call{SQL: sql, Args: values})
+	}
+	got := &Expectation{kind: k, sql: sql, args: values, checkArgs: len(values) > 0}
+	if f.next == len(f.expected) {
+		return nil, f.fail("unexpected %s", got)
+	}
+	e := f.expected[f.next]
+	switch {
+	case e.kind != k:
+		return nil, f.fail("got %s, want %s", got, e)
+	case e.matches == nil && e.sql != sql, e.matches != nil && !e.matches(sql):
+		return nil, f.fail("got %s, want %s", got, e)
+	case e.checkArgs && !reflect.DeepEqual(e.args, values):
+		return nil, f.fail("got %s, want %s", got, e)
+	}
+	f.next++
+	return e, e.err
+}
+
+// fail records a failure and returns it as the statement error
+func (f *Fake) fail(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	f.failures = append(f.failures, msg)
+	return errors.New("mysqltest: " + msg)
+}
+
+// collapse replaces whitespace runs of sql with one space
+func collapse(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+type connector struct {
+	f *Fake
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) { return &conn{c.f}, nil }
+func (c connector) Driver() driver.Driver                            { return fakeDriver{c.f} }
+
+type fakeDriver struct {
+	f *Fake
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return &conn{d.f}, nil }
+
+type conn struct {
+	f *Fake
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c, query}, nil
+}
+func (c *conn) Close() error              { return nil }
+func (c *conn) Begin() (driver.Tx, error) { return c.BeginTx(context.Background(), driver.TxOptions{}) }
+
+// CheckNamedValue converts args like go-sql-driver
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	return driverutil.CheckNamedValue(nv)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.f.match(kindBegin, "", nil); err != nil {
+		return nil, err
+	}
+	return tx{c.f}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.f.match(kindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return driverutil.Result{}, nil
+	}
+	return e.result, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.f.match(kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]driverutil.ResultSet, len(e.rows))
+	for i, rows := range e.rows {
+		sets[i] = rows.set
+	}
+	return driverutil.NewRows(sets...), nil
+}
+
+// stmt prepared statement, run like the unprepared one
+type stmt struct {
+	c     *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), driverutil.Named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), driverutil.Named(args))
+}
+
+type tx struct {
+	f *Fake
+}
+
+func (t tx) Commit() error {
+	_, err := t.f.match(kindCommit, "", nil)
+	return err
+}
+
+func (t tx) Rollback() error {
+	_, err := t.f.match(kindRollback, "", nil)
+	return err
+}
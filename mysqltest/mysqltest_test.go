@@ -0,0 +1,147 @@
+package mysqltest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+// users service under test, written against mysql.Querier
+func userNames(db mysql.Querier, ids []int) ([]string, error) {
+	var users []struct {
+		Name string `db:"name"`
+	}
+	if err := db.ScanRows(&users, "select name from users where id in (?)", ids); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Name
+	}
+	return names, nil
+}
+
+func TestFake(t *testing.T) {
+	db := NewFake(t)
+	db.ExpectQuery("select name  from users\n where id in (?, ?)").WithArgs(1, 2).
+		WillReturnRows(NewRows("name").AddRow("alice").AddRow("bob"))
+	db.ExpectExec("insert `users` (`name`) values ('carol')").WillReturnResult(3, 1)
+	db.ExpectQuery("select 1; select 2").WillReturnRows(NewRows("1").AddRow(1), NewRows("2").AddRow(nil))
+	db.ExpectExec("DELETE FROM `users` WHERE `id` = 0").MatchDigest().WillReturnError(errors.New("locked"))
+
+	names, err := userNames(db, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+
+	id, err := db.Insert("users", []string{"name"}, []interface{}{[]string{"carol"}})
+	if err != nil || id != 3 {
+		t.Errorf("Insert = %d, %v, want 3", id, err)
+	}
+
+	sets, err := db.SetRowsNil("select 1; select 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 2 || sets[0][0]["1"].String != "1" || sets[1][0]["2"].Valid {
+		t.Errorf("SetRowsNil = %v", sets)
+	}
+
+	if _, err := db.Delete("users", map[string]int{"id": 9}); err == nil || err.Error() != "locked" {
+		t.Errorf("Delete = %v, want locked", err)
+	}
+
+	calls := db.Calls()
+	if len(calls) != 4 || calls[0].SQL != "select name from users where id in (?, ?)" || !reflect.DeepEqual(calls[0].Args, []interface{}{int64(1), int64(2)}) {
+		t.Errorf("calls = %+v", calls)
+	}
+}
+
+func TestFakeTx(t *testing.T) {
+	db := NewFake(t)
+	db.ExpectBegin()
+	db.ExpectExec("^update `users` set .* where `id`=\\?$").MatchRegexp().WithArgs("x", 1).WillReturnResult(0, 1)
+	db.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q mysql.Querier = tx
+	if n, err := q.Update("users", map[string]string{"name": "x"}, map[string]int{"id": 1}); err != nil || n != 1 {
+		t.Errorf("Update = %d, %v", n, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// errorRecorder records Error calls instead of failing the test
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *errorRecorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func TestFakeUnmet(t *testing.T) {
+	r := &errorRecorder{TB: t}
+	t.Cleanup(func() {
+		if len(r.errors) != 1 {
+			t.Errorf("cleanup errors = %q, want 1", r.errors)
+		}
+	})
+	db := NewFake(r)
+	db.ExpectExec("DELETE FROM a").WithArgs(1)
+	db.ExpectQuery("select 1")
+
+	if _, err := db.Query("DELETE FROM a", 2); err == nil {
+		t.Error("exec with other args succeeded")
+	}
+	if _, err := db.Query("TRUNCATE b"); err == nil {
+		t.Error("unexpected exec succeeded")
+	}
+	err := db.ExpectationsWereMet()
+	if err == nil {
+		t.Fatal("ExpectationsWereMet = nil")
+	}
+	for _, want := range []string{`with args [2]`, `want exec "DELETE FROM a" with args [1]`, `not received: exec "DELETE FROM a"`, `not received: query "select 1"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ExpectationsWereMet = %v, want %s", err, want)
+		}
+	}
+}
+
+func TestFakeMatch(t *testing.T) {
+	r := &errorRecorder{TB: t}
+	db := NewFake(r)
+	db.ExpectExec("DELETE FROM a WHERE id = 1")
+	db.ExpectExec("delete from `a` where `id` = 1").MatchDigest()
+	db.ExpectQuery("select (.+) from b").MatchRegexp()
+
+	if _, err := db.Query("DELETE FROM a WHERE id = 2"); err == nil {
+		t.Error("exec of other literal matched")
+	}
+	if _, err := db.Query("DELETE FROM a WHERE id = 1"); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Query("DELETE FROM a WHERE id = 2"); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Rows("select id from b"); err != nil {
+		t.Error(err)
+	}
+	err := db.ExpectationsWereMet()
+	if want := `got exec "DELETE FROM a WHERE id = 2", want exec "DELETE FROM a WHERE id = 1"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("ExpectationsWereMet = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import "database/sql/driver"
+
+// DefaultStmtCacheSize number of prepared statements cached by DB
+const DefaultStmtCacheSize = 64
+
+// DefaultMaxInListSize number of values expanded into one IN list
+const DefaultMaxInListSize = 1000
+
+// Option configures DB created by New
+type Option func(*options)
+
+type options struct {
+	stmtCacheSize     int
+	maxInListSize     int
+	interpolateParams bool
+	jsonObjects       bool
+	jsonCast          bool
+	interceptors      []Interceptor
+	connector         driver.Connector
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		stmtCacheSize: DefaultStmtCacheSize,
+		maxInListSize: DefaultMaxInListSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithStmtCacheSize sets number of prepared statements kept open, 0 disables the cache
+func WithStmtCacheSize(size int) Option {
+	return func(o *options) {
+		o.stmtCacheSize = size
+	}
+}
+
+// WithMaxInListSize caps the number of values expanded into one IN list,
+// longer slices bound to `expr IN (?)` are split into several IN lists
+// joined with OR (AND for NOT IN), 0 never splits
+func WithMaxInListSize(size int) Option {
+	return func(o *options) {
+		o.maxInListSize = size
+	}
+}
+
+// WithInterpolateParams uses driver's client side interpolateParams instead of
+// server side prepared statements, for proxies that do not support prepares.
+// Statement cache is disabled
+func WithInterpolateParams() Option {
+	return func(o *options) {
+		o.interpolateParams = true
+	}
+}
+
+// WithJSONObjects stores maps, structs and slices nested in Insert rows and
+// Update data as JSON strings, cast wraps them in CAST(... AS JSON)
+func WithJSONObjects(cast bool) Option {
+	return func(o *options) {
+		o.jsonObjects = true
+		o.jsonCast = cast
+	}
+}
+
+// WithConnector opens connections with c instead of the driver of the config,
+// such as a replay.Recorder wrapping MySQL.NewConnector(config). The config
+// still sets the schema and time zone of DB, its database is not created
+func WithConnector(c driver.Connector) Option {
+	return func(o *options) {
+		o.connector = c
+	}
+}
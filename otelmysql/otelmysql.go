@@ -0,0 +1,141 @@
+// Package otelmysql traces statements of mysql.DB and mysql.Tx with OpenTelemetry
+// following the database semantic conventions
+package otelmysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	mysql "github.com/vinhjaxt/mysql-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName name of the tracer
+const instrumentationName = "github.com/vinhjaxt/mysql-go/otelmysql"
+
+// Option configures the tracing interceptor
+type Option func(*tracer)
+
+// WithTracerProvider sets provider of the tracer, global provider (no-op
+// unless configured) by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *tracer) {
+		t.provider = provider
+	}
+}
+
+// WithStatement records db.statement with literals replaced by ?, see mysql.Normalize
+func WithStatement() Option {
+	return func(t *tracer) {
+		t.statement = true
+	}
+}
+
+// tracer interceptor starting a span for each statement
+type tracer struct {
+	provider  trace.TracerProvider
+	tracer    trace.Tracer
+	statement bool
+	attrs     []attribute.KeyValue // connection attributes of every span
+	dbName    string
+}
+
+// NewInterceptor returns a mysql.Interceptor tracing statements of the
+// database described by config, register it with mysql.WithInterceptors
+func NewInterceptor(config *MySQL.Config, opts ...Option) mysql.Interceptor {
+	t := &tracer{
+		dbName: config.DBName,
+		attrs: []attribute.KeyValue{
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.name", config.DBName),
+		},
+	}
+	if config.Net == "unix" {
+		t.attrs = append(t.attrs, attribute.String("server.address", config.Addr), attribute.String("network.transport", "unix"))
+	} else if host, port, err := net.SplitHostPort(config.Addr); err == nil {
+		t.attrs = append(t.attrs, attribute.String("server.address", host))
+		if p, err := strconv.Atoi(port); err == nil {
+			t.attrs = append(t.attrs, attribute.Int("server.port", p))
+		}
+	} else {
+		t.attrs = append(t.attrs, attribute.String("server.address", config.Addr))
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.provider == nil {
+		t.provider = otel.GetTracerProvider()
+	}
+	t.tracer = t.provider.Tracer(instrumentationName)
+	return t
+}
+
+func (t *tracer) Before(ctx context.Context, info *mysql.QueryInfo) context.Context {
+	operation := operation(info)
+	name := operation + " " + t.dbName
+	if info.Table != "" {
+		name += "." + info.Table
+	}
+
+	attrs := append(make([]attribute.KeyValue, 0, len(t.attrs)+3), t.attrs...)
+	attrs = append(attrs, attribute.String("db.operation", operation))
+	if info.Table != "" {
+		attrs = append(attrs, attribute.String("db.sql.table", info.Table))
+	}
+	if t.statement {
+		attrs = append(attrs, attribute.String("db.statement", mysql.Normalize(info.SQL)))
+	}
+	ctx, _ = t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (t *tracer) After(ctx context.Context, info *mysql.QueryInfo, result sql.Result, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		span.End()
+		return
+	}
+	if returnsRows(info.Op) {
+		span.SetAttributes(attribute.Int64("db.response.returned_rows", info.RowsAffected))
+	}
+	if err != nil && err != sql.ErrNoRows {
+		var mErr *MySQL.MySQLError
+		if errors.As(err, &mErr) {
+			span.SetAttributes(attribute.Int("db.mysql.error_number", int(mErr.Number)))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// returnsRows reports whether op reads rows, for the others RowsAffected
+// counts changed rows
+func returnsRows(op mysql.Op) bool {
+	switch op {
+	case mysql.OpSingle, mysql.OpRow, mysql.OpRows, mysql.OpSetRows, mysql.OpSetRowsNil, mysql.OpScanRow, mysql.OpScanRows:
+		return true
+	}
+	return false
+}
+
+// operation returns sql keyword of the statement, like SELECT or UPDATE
+func operation(info *mysql.QueryInfo) string {
+	sqlQuery := strings.TrimLeft(info.SQL, " \t\r\n(")
+	end := strings.IndexAny(sqlQuery, " \t\r\n(;")
+	if end < 0 {
+		end = len(sqlQuery)
+	}
+	if end == 0 {
+		return strings.ToUpper(string(info.Op))
+	}
+	return strings.ToUpper(sqlQuery[:end])
+}
@@ -0,0 +1,80 @@
+package otelmysql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/otelmysql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInterceptor(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	config := mysql.NewConfig(&mysql.Config{DBName: "test", Host: "localhost", Port: 3306})
+	interceptor := otelmysql.NewInterceptor(config, otelmysql.WithTracerProvider(provider), otelmysql.WithStatement())
+
+	info := &mysql.QueryInfo{Op: mysql.OpUpdate, Table: "users", SQL: "update `users` set `name`='Vinh', `n1`=12 where `id`=?", RowsAffected: 1}
+	ctx := interceptor.Before(context.Background(), info)
+	interceptor.After(ctx, info, nil, &MySQL.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "UPDATE test.users" {
+		t.Errorf("span name = %s", span.Name)
+	}
+	want := map[attribute.Key]attribute.Value{
+		"db.system":             attribute.StringValue("mysql"),
+		"db.name":               attribute.StringValue("test"),
+		"db.operation":          attribute.StringValue("UPDATE"),
+		"db.sql.table":          attribute.StringValue("users"),
+		"db.statement":          attribute.StringValue("UPDATE `users` SET `name` = ? , `n1` = ? WHERE `id` = ?"),
+		"server.address":        attribute.StringValue("localhost"),
+		"server.port":           attribute.IntValue(3306),
+		"db.mysql.error_number": attribute.IntValue(1062),
+	}
+	got := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes {
+		got[attr.Key] = attr.Value
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, got[key].Emit(), value.Emit())
+		}
+	}
+	if _, ok := got["db.response.returned_rows"]; ok {
+		t.Errorf("db.response.returned_rows set on UPDATE")
+	}
+	if span.Status.Code != codes.Error || len(span.Events) != 1 {
+		t.Errorf("error not recorded: %+v", span.Status)
+	}
+
+	info = &mysql.QueryInfo{Op: mysql.OpRows, Table: "users", SQL: "select * from `users`", RowsAffected: 2}
+	interceptor.After(interceptor.Before(context.Background(), info), info, nil, nil)
+	spans = exporter.GetSpans()
+	var returned attribute.Value
+	for _, attr := range spans[len(spans)-1].Attributes {
+		if attr.Key == "db.response.returned_rows" {
+			returned = attr.Value
+		}
+	}
+	if returned != attribute.Int64Value(2) {
+		t.Errorf("db.response.returned_rows = %v, want 2", returned.Emit())
+	}
+}
+
+func TestNoopProvider(t *testing.T) {
+	interceptor := otelmysql.NewInterceptor(mysql.NewConfig(&mysql.Config{DBName: "test", UnixSocket: "/tmp/mysql.sock"}))
+	info := &mysql.QueryInfo{Op: mysql.OpQuery, SQL: "select 1"}
+	interceptor.After(interceptor.Before(context.Background(), info), info, nil, errors.New("x"))
+}
@@ -0,0 +1,109 @@
+// Package prommysql exports mysql.Metrics to Prometheus
+package prommysql
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+// Sink mysql.MetricsSink registering Prometheus collectors:
+// <namespace>_queries_total{op,table,status}, <namespace>_query_duration_seconds{op,table}
+// and connection pool statistics read at scrape time
+type Sink struct {
+	queries  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	mu    sync.Mutex
+	stats sql.DBStats
+
+	maxOpen, open, inUse, idle                       *prometheus.Desc
+	waitCount, waitDuration, maxIdleClosed, lifetime *prometheus.Desc
+}
+
+// NewSink returns a Sink with collectors registered on reg
+func NewSink(reg prometheus.Registerer, namespace string) (*Sink, error) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pool", name), help, nil, nil)
+	}
+	s := &Sink{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queries_total",
+			Help:      "Statements run by DB helpers.",
+		}, []string{"op", "table", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "query_duration_seconds",
+			Help:      "Latency of statements run by DB helpers.",
+			Buckets:   mysql.LatencyBuckets,
+		}, []string{"op", "table"}),
+
+		maxOpen:       desc("max_open_connections", "Maximum number of open connections."),
+		open:          desc("open_connections", "Established connections, in use and idle."),
+		inUse:         desc("in_use_connections", "Connections currently in use."),
+		idle:          desc("idle_connections", "Idle connections."),
+		waitCount:     desc("wait_count_total", "Connections waited for."),
+		waitDuration:  desc("wait_duration_seconds_total", "Time blocked waiting for a connection."),
+		maxIdleClosed: desc("max_idle_closed_total", "Connections closed due to SetMaxIdleConns."),
+		lifetime:      desc("max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime."),
+	}
+	for _, c := range []prometheus.Collector{s.queries, s.duration, poolCollector{s}} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SetPoolStats implements mysql.MetricsSink
+func (s *Sink) SetPoolStats(stats sql.DBStats) {
+	s.mu.Lock()
+	s.stats = stats
+	s.mu.Unlock()
+}
+
+// ObserveQuery implements mysql.MetricsSink
+func (s *Sink) ObserveQuery(op mysql.Op, table string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	s.queries.WithLabelValues(string(op), table, status).Inc()
+	s.duration.WithLabelValues(string(op), table).Observe(duration.Seconds())
+}
+
+// poolCollector exports latest pool statistics
+type poolCollector struct {
+	s *Sink
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.s.maxOpen, c.s.open, c.s.inUse, c.s.idle, c.s.waitCount, c.s.waitDuration, c.s.maxIdleClosed, c.s.lifetime} {
+		ch <- desc
+	}
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.s.mu.Lock()
+	stats := c.s.stats
+	c.s.mu.Unlock()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(c.s.maxOpen, float64(stats.MaxOpenConnections))
+	gauge(c.s.open, float64(stats.OpenConnections))
+	gauge(c.s.inUse, float64(stats.InUse))
+	gauge(c.s.idle, float64(stats.Idle))
+	counter(c.s.waitCount, float64(stats.WaitCount))
+	counter(c.s.waitDuration, stats.WaitDuration.Seconds())
+	counter(c.s.maxIdleClosed, float64(stats.MaxIdleClosed))
+	counter(c.s.lifetime, float64(stats.MaxLifetimeClosed))
+}
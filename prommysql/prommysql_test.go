@@ -0,0 +1,44 @@
+package prommysql_test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/prommysql"
+)
+
+func TestSink(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	sink, err := prommysql.NewSink(reg, "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.SetPoolStats(sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 7})
+	sink.ObserveQuery(mysql.OpUpdate, "users", 20*time.Millisecond, nil)
+	sink.ObserveQuery(mysql.OpUpdate, "users", time.Second, errors.New("x"))
+
+	expected := `
+# HELP mysql_pool_open_connections Established connections, in use and idle.
+# TYPE mysql_pool_open_connections gauge
+mysql_pool_open_connections 3
+# HELP mysql_pool_wait_count_total Connections waited for.
+# TYPE mysql_pool_wait_count_total counter
+mysql_pool_wait_count_total 7
+# HELP mysql_queries_total Statements run by DB helpers.
+# TYPE mysql_queries_total counter
+mysql_queries_total{op="update",status="error",table="users"} 1
+mysql_queries_total{op="update",status="ok",table="users"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "mysql_pool_open_connections", "mysql_pool_wait_count_total", "mysql_queries_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(reg, "mysql_query_duration_seconds"); n != 1 {
+		t.Errorf("got %d duration histograms, want 1", n)
+	}
+}
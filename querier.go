@@ -0,0 +1,27 @@
+package mysql
+
+import "database/sql"
+
+// Querier statement helpers of DB, implemented by *DB, *Tx and mysqltest.Fake.
+// Depend on it instead of *DB to swap the database in unit tests
+type Querier interface {
+	Single(sqlQuery string, values ...interface{}) (*sql.NullString, error)
+	Row(sqlQuery string, args ...interface{}) (map[string]*sql.NullString, error)
+	Rows(sqlQuery string, args ...interface{}) ([]map[string]*sql.NullString, error)
+	SetRows(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error)
+	SetRowsNil(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error)
+	ScanRow(dest interface{}, sqlQuery string, args ...interface{}) error
+	ScanRows(dest interface{}, sqlQuery string, args ...interface{}) error
+	Insert(table string, columns []string, data []interface{}) (int64, error)
+	InsertUpdate(table string, columns []string, data []interface{}) (sql.Result, error)
+	Update(table string, data interface{}, where interface{}, limits ...uint64) (int64, error)
+	Delete(table string, where interface{}, limits ...uint64) (int64, error)
+	Query(sql string, values ...interface{}) (sql.Result, error)
+	Escape(val interface{}, stringifyObjects bool) (string, error)
+	EscapeID(val string, forbidQualified bool) string
+}
+
+var (
+	_ Querier = (*DB)(nil)
+	_ Querier = (*Tx)(nil)
+)
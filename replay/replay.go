@@ -0,0 +1,612 @@
+// Package replay records statements of a database/sql connector to a golden
+// file and serves them back without a server, for fast hermetic tests of
+// realistic results.
+//
+// Record once against MySQL, then replay in CI:
+//
+//	var record = flag.Bool("record", false, "record golden files against MySQL")
+//
+//	func newDB(t *testing.T) *mysql.DB {
+//		config := mysql.NewConfig(&mysql.Config{User: "root", Host: "localhost", DBName: "test"})
+//		var connector driver.Connector
+//		if *record {
+//			base, err := MySQL.NewConnector(config)
+//			...
+//			recorder := replay.NewRecorder(base)
+//			t.Cleanup(func() { recorder.Save("testdata/users.json") })
+//			connector = recorder
+//		} else {
+//			connector, err = replay.Load("testdata/users.json")
+//		}
+//		db, err := mysql.New(config, mysql.WithConnector(connector))
+//		...
+//	}
+//
+// A statement replays the first unused recording of the same kind, SQL and
+// args, so tests must run the same statements, concurrent ones in any order.
+// Transactions always succeed in replay.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/vinhjaxt/mysql-go/internal/driverutil"
+)
+
+// statement recorded statement and its answer
+type statement struct {
+	Exec         bool        `json:"exec,omitempty"`
+	SQL          string      `json:"sql"`
+	Args         []value     `json:"args,omitempty"`
+	Sets         []resultSet `json:"sets,omitempty"`
+	LastInsertID int64       `json:"last_insert_id,omitempty"`
+	RowsAffected int64       `json:"rows_affected,omitempty"`
+	Error        *failure    `json:"error,omitempty"`
+}
+
+// key identifies recordings a statement can replay
+func (s *statement) key() string {
+	args, _ := json.Marshal(s.Args)
+	return fmt.Sprintf("%t\x00%s\x00%s", s.Exec, s.SQL, args)
+}
+
+// rows returns the recorded result sets as driver.Rows
+func (s *statement) rows() driver.Rows {
+	sets := make([]driverutil.ResultSet, len(s.Sets))
+	for i, set := range s.Sets {
+		sets[i].Columns = set.Columns
+		sets[i].Rows = make([][]driver.Value, len(set.Rows))
+		for j, row := range set.Rows {
+			sets[i].Rows[j] = make([]driver.Value, len(row))
+			for k, v := range row {
+				sets[i].Rows[j][k] = v.Value
+			}
+		}
+	}
+	return driverutil.NewRows(sets...)
+}
+
+// resultSet columns and rows of a query
+type resultSet struct {
+	Columns []string  `json:"columns"`
+	Rows    [][]value `json:"rows"`
+}
+
+// failure error of a statement, Number is set for server errors
+type failure struct {
+	Number  uint16 `json:"number,omitempty"`
+	Message string `json:"message"`
+}
+
+func newFailure(err error) *failure {
+	var mysqlErr *MySQL.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return &failure{Number: mysqlErr.Number, Message: mysqlErr.Message}
+	}
+	return &failure{Message: err.Error()}
+}
+
+func (f *failure) err() error {
+	if f.Number != 0 {
+		return &MySQL.MySQLError{Number: f.Number, Message: f.Message}
+	}
+	return errors.New(f.Message)
+}
+
+// value driver.Value keeping its type in JSON: {"int64": 1}, {"bytes": "a"}
+type value struct {
+	driver.Value
+}
+
+func (v value) MarshalJSON() ([]byte, error) {
+	var typ string
+	var data interface{} = v.Value
+	switch val := v.Value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case int64:
+		typ = "int64"
+	case uint64:
+		typ = "uint64"
+	case float64:
+		typ = "float64"
+	case bool:
+		typ = "bool"
+	case string:
+		typ = "string"
+	case []byte:
+		if utf8.Valid(val) {
+			typ, data = "bytes", string(val)
+		} else {
+			typ, data = "base64", base64.StdEncoding.EncodeToString(val)
+		}
+	case time.Time:
+		typ, data = "time", val.Format(time.RFC3339Nano)
+	default:
+		return nil, fmt.Errorf("replay: unsupported value %T", v.Value)
+	}
+	return json.Marshal(map[string]interface{}{typ: data})
+}
+
+func (v *value) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		v.Value = nil
+		return nil
+	}
+	var typed map[string]json.RawMessage
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	if len(typed) != 1 {
+		return fmt.Errorf("replay: value %s, want one type", data)
+	}
+	for typ, raw := range typed {
+		var err error
+		switch typ {
+		case "int64":
+			var n int64
+			err = json.Unmarshal(raw, &n)
+			v.Value = n
+		case "uint64":
+			var n uint64
+			err = json.Unmarshal(raw, &n)
+			v.Value = n
+		case "float64":
+			var f float64
+			err = json.Unmarshal(raw, &f)
+			v.Value = f
+		case "bool":
+			var b bool
+			err = json.Unmarshal(raw, &b)
+			v.Value = b
+		case "string":
+			var s string
+			err = json.Unmarshal(raw, &s)
+			v.Value = s
+		case "bytes":
+			var s string
+			err = json.Unmarshal(raw, &s)
+			v.Value = []byte(s)
+		case "base64":
+			var s string
+			if err = json.Unmarshal(raw, &s); err == nil {
+				v.Value, err = base64.StdEncoding.DecodeString(s)
+			}
+		case "time":
+			var s string
+			if err = json.Unmarshal(raw, &s); err == nil {
+				v.Value, err = time.Parse(time.RFC3339Nano, s)
+			}
+		default:
+			return fmt.Errorf("replay: unknown value type %q", typ)
+		}
+		if err != nil {
+			return fmt.Errorf("replay: %s value: %v", typ, err)
+		}
+	}
+	return nil
+}
+
+// values wraps args of a statement
+func values(args []driver.NamedValue) []value {
+	if len(args) == 0 {
+		return nil
+	}
+	vs := make([]value, len(args))
+	for i, arg := range args {
+		vs[i] = value{arg.Value}
+	}
+	return vs
+}
+
+// golden file content
+type golden struct {
+	Statements []*statement `json:"statements"`
+}
+
+// Recorder driver.Connector recording statements run on connections of base
+type Recorder struct {
+	base driver.Connector
+
+	mu         sync.Mutex
+	statements []*statement
+}
+
+// NewRecorder returns a Recorder of base, such as MySQL.NewConnector(config)
+func NewRecorder(base driver.Connector) *Recorder {
+	return &Recorder{base: base}
+}
+
+// Connect returns a recording connection of base
+func (r *Recorder) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := r.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recordConn{r: r, conn: conn}, nil
+}
+
+// Driver returns driver of base
+func (r *Recorder) Driver() driver.Driver {
+	return r.base.Driver()
+}
+
+// Save writes the statements recorded so far to the golden file path, one
+// statement per line so changes diff well
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	buf := []byte(`{"statements": [`)
+	for i, s := range r.statements {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, "\n  "...)
+		buf = append(buf, data...)
+	}
+	buf = append(buf, "\n]}\n"...)
+	return os.WriteFile(path, buf, 0o644)
+}
+
+func (r *Recorder) add(s *statement) {
+	r.mu.Lock()
+	r.statements = append(r.statements, s)
+	r.mu.Unlock()
+}
+
+// exec records the result of exec
+func (r *Recorder) exec(query string, args []driver.NamedValue, exec func() (driver.Result, error)) (driver.Result, error) {
+	res, err := exec()
+	if err == driver.ErrSkip || err == driver.ErrBadConn {
+		// retried by database/sql
+		return res, err
+	}
+	s := &statement{Exec: true, SQL: query, Args: values(args)}
+	if err != nil {
+		s.Error = newFailure(err)
+	} else {
+		s.LastInsertID, _ = res.LastInsertId()
+		s.RowsAffected, _ = res.RowsAffected()
+	}
+	r.add(s)
+	return res, err
+}
+
+// query records every result set of query and serves them from memory
+func (r *Recorder) query(query string, args []driver.NamedValue, run func() (driver.Rows, error)) (driver.Rows, error) {
+	rows, err := run()
+	if err == driver.ErrSkip || err == driver.ErrBadConn {
+		return rows, err
+	}
+	s := &statement{SQL: query, Args: values(args)}
+	if err == nil {
+		s.Sets, err = readSets(rows)
+	}
+	if err != nil {
+		s.Error = newFailure(err)
+		r.add(s)
+		return nil, err
+	}
+	r.add(s)
+	return s.rows(), nil
+}
+
+// readSets reads and closes rows
+func readSets(rows driver.Rows) ([]resultSet, error) {
+	defer rows.Close()
+	var sets []resultSet
+	for {
+		set := resultSet{Columns: rows.Columns()}
+		dest := make([]driver.Value, len(set.Columns))
+		for {
+			err := rows.Next(dest)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			row := make([]value, len(dest))
+			for i, v := range dest {
+				if b, ok := v.([]byte); ok {
+					// drivers reuse their buffers
+					v = append([]byte(nil), b...)
+				}
+				row[i] = value{v}
+			}
+			set.Rows = append(set.Rows, row)
+		}
+		sets = append(sets, set)
+		next, ok := rows.(driver.RowsNextResultSet)
+		if !ok || !next.HasNextResultSet() {
+			return sets, nil
+		}
+		if err := next.NextResultSet(); err == io.EOF {
+			return sets, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type recordConn struct {
+	r    *Recorder
+	conn driver.Conn
+}
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *recordConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if prepare, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = prepare.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recordStmt{c: c, stmt: stmt, query: query}, nil
+}
+
+func (c *recordConn) Close() error { return c.conn.Close() }
+
+func (c *recordConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if begin, ok := c.conn.(driver.ConnBeginTx); ok {
+		return begin.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *recordConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *recordConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *recordConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *recordConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.r.exec(query, args, func() (driver.Result, error) {
+		return execer.ExecContext(ctx, query, args)
+	})
+}
+
+func (c *recordConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.r.query(query, args, func() (driver.Rows, error) {
+		return queryer.QueryContext(ctx, query, args)
+	})
+}
+
+// recordStmt records statements run through database/sql prepares
+type recordStmt struct {
+	c     *recordConn
+	stmt  driver.Stmt
+	query string
+}
+
+func (s *recordStmt) Close() error  { return s.stmt.Close() }
+func (s *recordStmt) NumInput() int { return s.stmt.NumInput() }
+
+func (s *recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), driverutil.Named(args))
+}
+
+func (s *recordStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), driverutil.Named(args))
+}
+
+func (s *recordStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.r.exec(s.query, args, func() (driver.Result, error) {
+		if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+			return execer.ExecContext(ctx, args)
+		}
+		return s.stmt.Exec(driverutil.Unnamed(args))
+	})
+}
+
+func (s *recordStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.r.query(s.query, args, func() (driver.Rows, error) {
+		if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+			return queryer.QueryContext(ctx, args)
+		}
+		return s.stmt.Query(driverutil.Unnamed(args))
+	})
+}
+
+func (s *recordStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	// database/sql asks the conn only when the stmt does not check
+	return s.c.CheckNamedValue(nv)
+}
+
+// Replayer driver.Connector answering statements from a golden file
+type Replayer struct {
+	mu      sync.Mutex
+	pending map[string][]*statement // by key, in recording order
+}
+
+// Load returns a Replayer of the golden file path written by Recorder.Save
+func Load(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("replay: %s: %v", path, err)
+	}
+	r := &Replayer{pending: make(map[string][]*statement)}
+	for _, s := range g.Statements {
+		key := s.key()
+		r.pending[key] = append(r.pending[key], s)
+	}
+	return r, nil
+}
+
+// Connect returns a replaying connection
+func (r *Replayer) Connect(ctx context.Context) (driver.Conn, error) {
+	return &replayConn{r}, nil
+}
+
+// Driver returns a driver of replaying connections
+func (r *Replayer) Driver() driver.Driver {
+	return replayDriver{r}
+}
+
+// Unused returns the number of recordings not replayed yet
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, statements := range r.pending {
+		n += len(statements)
+	}
+	return n
+}
+
+// next removes and returns the first recording of exec or query with args
+func (r *Replayer) next(exec bool, query string, args []driver.NamedValue) (*statement, error) {
+	s := &statement{Exec: exec, SQL: query, Args: values(args)}
+	// recordings went through JSON, so must args
+	data, err := json.Marshal(s.Args)
+	if err != nil {
+		return nil, err
+	}
+	s.Args = nil
+	if err := json.Unmarshal(data, &s.Args); err != nil {
+		return nil, err
+	}
+	key := s.key()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := r.pending[key]
+	if len(statements) == 0 {
+		kind := "query"
+		if exec {
+			kind = "exec"
+		}
+		return nil, fmt.Errorf("replay: no recording of %s %q with args %s", kind, query, data)
+	}
+	r.pending[key] = statements[1:]
+	s = statements[0]
+	if s.Error != nil {
+		return nil, s.Error.err()
+	}
+	return s, nil
+}
+
+type replayDriver struct {
+	r *Replayer
+}
+
+func (d replayDriver) Open(name string) (driver.Conn, error) { return &replayConn{d.r}, nil }
+
+type replayConn struct {
+	r *Replayer
+}
+
+func (c *replayConn) Prepare(query string) (driver.Stmt, error) {
+	return &replayStmt{c, query}, nil
+}
+func (c *replayConn) Close() error                   { return nil }
+func (c *replayConn) Begin() (driver.Tx, error)      { return replayTx{}, nil }
+func (c *replayConn) Ping(ctx context.Context) error { return nil }
+
+// CheckNamedValue keeps unsigned integers uint64 like go-sql-driver, so
+// args match their recording
+func (c *replayConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return driverutil.CheckNamedValue(nv)
+}
+
+func (c *replayConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s, err := c.r.next(true, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driverutil.Result{InsertID: s.LastInsertID, Affected: s.RowsAffected}, nil
+}
+
+func (c *replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s, err := c.r.next(false, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.rows(), nil
+}
+
+type replayStmt struct {
+	c     *replayConn
+	query string
+}
+
+func (s *replayStmt) Close() error  { return nil }
+func (s *replayStmt) NumInput() int { return -1 }
+
+func (s *replayStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.c.ExecContext(context.Background(), s.query, driverutil.Named(args))
+}
+
+func (s *replayStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.QueryContext(context.Background(), s.query, driverutil.Named(args))
+}
+
+func (s *replayStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *replayStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+type replayTx struct{}
+
+func (replayTx) Commit() error   { return nil }
+func (replayTx) Rollback() error { return nil }
@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	mysql "github.com/vinhjaxt/mysql-go"
+	"github.com/vinhjaxt/mysql-go/mysqltest"
+)
+
+// expect scripts fake with the statements of outputs, avatar is the []byte
+// returned for the avatar column
+func expect(fake *mysqltest.Fake, avatar []byte) {
+	fake.ExpectQuery("SELECT @@SESSION.sql_mode").WillReturnRows(mysqltest.NewRows("@@SESSION.sql_mode").AddRow([]byte("STRICT_TRANS_TABLES")))
+	fake.ExpectQuery("select id, name, avatar from users where id in (?, ?)").WithArgs(1, 2).
+		WillReturnRows(mysqltest.NewRows("id", "name", "avatar").AddRow(1, []byte("user"), avatar).AddRow(2, []byte("user"), avatar))
+	fake.ExpectQuery("select 1; select 2").WillReturnRows(mysqltest.NewRows("1").AddRow(1), mysqltest.NewRows("2").AddRow(nil))
+	fake.ExpectExec("insert `users` (`name`) values ('a'), ('b')").WillReturnResult(7, 2)
+	fake.ExpectExec("update `users` set `name`=? where `id`=?").WithArgs("c", uint(1)).WillReturnResult(0, 1)
+	fake.ExpectExec("insert into users values ('dup')").WillReturnError(&MySQL.MySQLError{Number: 1062, Message: "Duplicate entry 'dup'"})
+}
+
+// outputs runs helpers on db and returns their results
+func outputs(t *testing.T, db *mysql.DB) []interface{} {
+	rows, err := db.Rows("select id, name, avatar from users where id in (?)", []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets, err := db.SetRowsNil("select 1; select 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.Insert("users", []string{"name"}, []interface{}{[]string{"a"}, []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := db.Update("users", map[string]string{"name": "c"}, map[string]uint{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, dupErr := db.Query("insert into users values ('dup')")
+	var mysqlErr *MySQL.MySQLError
+	if !errors.As(dupErr, &mysqlErr) || mysqlErr.Number != 1062 {
+		t.Errorf("duplicate insert = %v, want MySQL error 1062", dupErr)
+	}
+	return []interface{}{rows, sets, id, updated}
+}
+
+func TestRecordReplay(t *testing.T) {
+	config := mysql.NewConfig(&mysql.Config{DBName: "app"})
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	fake := mysqltest.NewFake(t)
+	avatar := []byte{0xff, 0x00}
+	expect(fake, avatar)
+	recorder := NewRecorder(fake.Connector())
+	db, err := mysql.New(config, mysql.WithConnector(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := outputs(t, db)
+	db.Close()
+	// drivers reuse their buffers, the recording must not
+	avatar[0] = 0
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"sql":"SELECT @@SESSION.sql_mode"`, `{"base64":"/wA="}`, `"number":1062`, `"exec":true`} {
+		if !strings.Contains(string(golden), want) {
+			t.Errorf("golden file misses %s:\n%s", want, golden)
+		}
+	}
+	calls := len(fake.Calls())
+
+	replayer, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err = mysql.New(config, mysql.WithConnector(replayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	replayed := outputs(t, db)
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed %v, recorded %v", replayed, recorded)
+	}
+	if len(fake.Calls()) != calls {
+		t.Errorf("replay reached the server")
+	}
+	if n := replayer.Unused(); n != 0 {
+		t.Errorf("Unused = %d, want 0", n)
+	}
+	if _, err := db.Rows("select id from users where id = ?", 3); err == nil || !strings.Contains(err.Error(), "no recording") {
+		t.Errorf("unrecorded query = %v, want no recording error", err)
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	for _, v := range []driver.Value{nil, int64(-1), uint64(1 << 63), 1.5, true, "s", []byte("é"), []byte{0xff}} {
+		data, err := value{v}.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got value
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Value, v) {
+			t.Errorf("%s = %#v, want %#v", data, got.Value, v)
+		}
+	}
+	var v value
+	if err := v.UnmarshalJSON([]byte(`{"uint": 1}`)); err == nil {
+		t.Error("unknown type decoded")
+	}
+}
@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ScanRow select one row into struct pointed by dest, columns are mapped to
+// fields by `db` tags (see TagName), unknown columns are ignored
+// return sql.ErrNoRows if no row found
+func (db *DB) ScanRow(dest interface{}, sqlQuery string, args ...interface{}) (err error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("mysql.scanrow: dest must be a pointer to struct")
+	}
+
+	rows, c, err := db.query(OpScanRow, sqlQuery, args)
+	if err != nil {
+		return err
+	}
+	defer func() { c.after(nil, rowCount(err == nil), err) }()
+	defer rows.Close()
+
+	if rows.Next() == false {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanStruct(rows, columnFields(v.Elem().Type(), columns), v.Elem())
+}
+
+// ScanRows select rows into slice of structs or struct pointers pointed by dest,
+// columns are mapped to fields by `db` tags (see TagName)
+func (db *DB) ScanRows(dest interface{}, sqlQuery string, args ...interface{}) (err error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return errors.New("mysql.scanrows: dest must be a pointer to slice")
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("mysql.scanrows: dest must be a pointer to slice of structs")
+	}
+
+	rows, c, err := db.query(OpScanRows, sqlQuery, args)
+	if err != nil {
+		return err
+	}
+	defer func() { c.after(nil, int64(slice.Len()), err) }()
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := columnFields(elemType, columns)
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := scanStruct(rows, fields, elem.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice = reflect.Append(slice, elem)
+		} else {
+			slice = reflect.Append(slice, elem.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	v.Elem().Set(slice)
+	return nil
+}
+
+// columnFields returns field of each column, nil for unknown columns.
+// Column names match exactly first, then case-insensitively like MySQL does
+func columnFields(t reflect.Type, columns []string) []*structField {
+	fields := structFields(t)
+	columnFields := make([]*structField, len(columns))
+	for i, column := range columns {
+		for j := range fields {
+			if fields[j].name == column {
+				columnFields[i] = &fields[j]
+				break
+			}
+		}
+		if columnFields[i] != nil {
+			continue
+		}
+		for j := range fields {
+			if strings.EqualFold(fields[j].name, column) {
+				columnFields[i] = &fields[j]
+				break
+			}
+		}
+	}
+	return columnFields
+}
+
+func scanStruct(rows *sql.Rows, fields []*structField, v reflect.Value) error {
+	scanArgs := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch {
+		case field == nil:
+			scanArgs[i] = new(sql.RawBytes)
+		case field.json:
+			scanArgs[i] = JSON(fieldByIndexAlloc(v, field.index).Addr().Interface())
+		default:
+			scanArgs[i] = fieldByIndexAlloc(v, field.index).Addr().Interface()
+		}
+	}
+	return rows.Scan(scanArgs...)
+}
@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type ScanBase struct {
+	ID int64 `db:"id"`
+}
+
+type scanMeta struct {
+	Theme string `json:"theme"`
+}
+
+type scanUser struct {
+	*ScanBase
+	Name   string         `db:"name"`
+	Email  sql.NullString `db:"email"`
+	Meta   scanMeta       `db:"meta,json"`
+	Secret string         `db:"-"`
+}
+
+func TestScanRows(t *testing.T) {
+	db, _ := newTestDB(t,
+		result("from users", []string{"ID", "name", "email", "meta", "extra"},
+			[]driver.Value{int64(1), "alice", nil, []byte(`{"theme":"dark"}`), "x"},
+			[]driver.Value{int64(2), "bob", "bob@example.com", []byte(`{}`), "y"},
+		),
+		result("from empty", []string{"id"}),
+	)
+
+	var users []*scanUser
+	if err := db.ScanRows(&users, "select * from users"); err != nil {
+		t.Fatal(err)
+	}
+	want := []*scanUser{
+		{ScanBase: &ScanBase{ID: 1}, Name: "alice", Meta: scanMeta{Theme: "dark"}},
+		{ScanBase: &ScanBase{ID: 2}, Name: "bob", Email: sql.NullString{String: "bob@example.com", Valid: true}},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("ScanRows = %+v, want %+v", users, want)
+	}
+
+	var user scanUser
+	if err := db.ScanRow(&user, "select * from users"); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || user.Name != "alice" {
+		t.Errorf("ScanRow = %+v", user)
+	}
+	if err := db.ScanRow(&user, "select * from empty"); err != sql.ErrNoRows {
+		t.Errorf("ScanRow of no rows = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.ScanRow(user, "select * from users"); err == nil {
+		t.Error("ScanRow into non-pointer succeeded")
+	}
+	if err := db.ScanRows(&[]int{}, "select * from users"); err == nil {
+		t.Error("ScanRows into slice of int succeeded")
+	}
+}
+
+func TestColumnFields(t *testing.T) {
+	typ := reflect.TypeOf(struct {
+		Name  string `db:"name"`
+		NAME  string `db:"NAME"`
+		Email string `db:"email"`
+	}{})
+	fields := columnFields(typ, []string{"NAME", "Email", "name", "other"})
+	var names []string
+	for _, field := range fields {
+		if field == nil {
+			names = append(names, "")
+			continue
+		}
+		names = append(names, field.name)
+	}
+	// exact match first, then case-insensitive
+	if want := []string{"NAME", "email", "name", ""}; !reflect.DeepEqual(names, want) {
+		t.Errorf("columnFields = %q, want %q", names, want)
+	}
+}
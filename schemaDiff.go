@@ -0,0 +1,458 @@
+package mysql
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaChange one difference between two schemas and the statement fixing it
+type SchemaChange struct {
+	Table       string
+	Description string // human readable, like "modify column users.name: varchar(50) NOT NULL -> varchar(100) NOT NULL"
+	SQL         string
+	// Destructive change may lose data: drops and type narrowing
+	Destructive bool
+}
+
+// SchemaDiff changes bringing a schema in line with another, in execution order
+type SchemaDiff struct {
+	Changes []SchemaChange
+}
+
+// tableSchema table of a loaded schema
+type tableSchema struct {
+	Table
+	columns     []Column
+	indexes     []Index
+	foreignKeys []ForeignKey
+}
+
+// DiffSchemas compares tables, columns, indexes, foreign keys, charsets and
+// engines of databases a and b, the changes bring b in line with a.
+// Views are not compared
+func DiffSchemas(a, b *DB) (*SchemaDiff, error) {
+	from, err := loadSchema(b)
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadSchema(a)
+	if err != nil {
+		return nil, err
+	}
+
+	// foreign keys are dropped first and added last so that tables and
+	// columns they reference can change in between
+	var dropKeys, creates, alters, addKeys, drops []SchemaChange
+	for _, name := range sortedTables(to) {
+		target, current := to[name], from[name]
+		if current == nil {
+			create, err := a.CreateStatement(name)
+			if err != nil {
+				return nil, err
+			}
+			creates = append(creates, SchemaChange{
+				Table:       name,
+				Description: "create table " + name,
+				SQL:         autoIncrementOption.ReplaceAllString(create, ""),
+			})
+			continue
+		}
+		dropKeys, addKeys = diffForeignKeys(name, current.foreignKeys, target.foreignKeys, dropKeys, addKeys)
+		alters = diffTable(b.esc, current, target, alters)
+	}
+	for _, name := range sortedTables(from) {
+		if to[name] == nil {
+			for _, key := range from[name].foreignKeys {
+				dropKeys = append(dropKeys, dropForeignKey(name, key))
+			}
+			drops = append(drops, SchemaChange{
+				Table:       name,
+				Description: "drop table " + name,
+				SQL:         "DROP TABLE " + EscapeID(name, true),
+				Destructive: true,
+			})
+		}
+	}
+
+	diff := &SchemaDiff{}
+	for _, changes := range [][]SchemaChange{dropKeys, creates, alters, addKeys, drops} {
+		diff.Changes = append(diff.Changes, changes...)
+	}
+	return diff, nil
+}
+
+// DDL returns statements of every change in execution order
+func (d *SchemaDiff) DDL() []string {
+	ddl := make([]string, len(d.Changes))
+	for i, change := range d.Changes {
+		ddl[i] = change.SQL
+	}
+	return ddl
+}
+
+// Destructive returns changes that may lose data
+func (d *SchemaDiff) Destructive() []SchemaChange {
+	var changes []SchemaChange
+	for _, change := range d.Changes {
+		if change.Destructive {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Report returns one line per change, destructive ones marked with !
+func (d *SchemaDiff) Report() string {
+	if len(d.Changes) == 0 {
+		return "schemas are identical\n"
+	}
+	var b strings.Builder
+	for _, change := range d.Changes {
+		if change.Destructive {
+			b.WriteString("! ")
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(change.Description)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// autoIncrementOption AUTO_INCREMENT table option of SHOW CREATE TABLE
+var autoIncrementOption = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// loadSchema reads base tables of db
+func loadSchema(db *DB) (map[string]*tableSchema, error) {
+	tables, err := db.Tables()
+	if err != nil {
+		return nil, err
+	}
+	schema := make(map[string]*tableSchema)
+	for _, table := range tables {
+		if table.Type != "BASE TABLE" {
+			continue
+		}
+		t := &tableSchema{Table: table}
+		if t.columns, err = db.Columns(table.Name); err != nil {
+			return nil, err
+		}
+		if t.indexes, err = db.Indexes(table.Name); err != nil {
+			return nil, err
+		}
+		if t.foreignKeys, err = db.ForeignKeys(table.Name); err != nil {
+			return nil, err
+		}
+		schema[table.Name] = t
+	}
+	return schema, nil
+}
+
+// sortedTables returns table names, referenced tables before their referrers
+func sortedTables(schema map[string]*tableSchema) []string {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]string, 0, len(names))
+	visited := make(map[string]bool, len(names))
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] || schema[name] == nil {
+			return
+		}
+		visited[name] = true
+		for _, key := range schema[name].foreignKeys {
+			visit(key.RefTable)
+		}
+		sorted = append(sorted, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return sorted
+}
+
+// diffTable appends changes of table options, columns and indexes, string
+// literals are escaped with esc of the altered database
+func diffTable(esc escaper, current, target *tableSchema, changes []SchemaChange) []SchemaChange {
+	name := target.Name
+	alter := "ALTER TABLE " + EscapeID(name, true) + " "
+	if target.Engine != "" && !strings.EqualFold(current.Engine, target.Engine) {
+		changes = append(changes, SchemaChange{
+			Table:       name,
+			Description: "engine of " + name + ": " + current.Engine + " -> " + target.Engine,
+			SQL:         alter + "ENGINE=" + target.Engine,
+		})
+	}
+	if target.Collation != "" && !strings.EqualFold(current.Collation, target.Collation) {
+		changes = append(changes, SchemaChange{
+			Table:       name,
+			Description: "default collation of " + name + ": " + current.Collation + " -> " + target.Collation,
+			SQL:         alter + "DEFAULT CHARSET=" + charsetOf(target.Collation) + " COLLATE=" + target.Collation,
+		})
+	}
+
+	currentColumns := make(map[string]*Column, len(current.columns))
+	for i := range current.columns {
+		currentColumns[strings.ToLower(current.columns[i].Name)] = &current.columns[i]
+	}
+	targetColumns := make(map[string]bool, len(target.columns))
+	after := " FIRST"
+	for i := range target.columns {
+		column := &target.columns[i]
+		targetColumns[strings.ToLower(column.Name)] = true
+		definition := columnDefinition(esc, column)
+		if existing := currentColumns[strings.ToLower(column.Name)]; existing == nil {
+			changes = append(changes, SchemaChange{
+				Table:       name,
+				Description: "add column " + name + "." + column.Name + ": " + definition,
+				SQL:         alter + "ADD COLUMN " + definition + after,
+			})
+		} else if old := columnDefinition(esc, existing); old != definition {
+			changes = append(changes, SchemaChange{
+				Table:       name,
+				Description: "modify column " + name + "." + column.Name + ": " + old + " -> " + definition,
+				SQL:         alter + "MODIFY COLUMN " + definition,
+				Destructive: narrows(existing, column),
+			})
+		}
+		after = " AFTER " + EscapeID(column.Name, true)
+	}
+	for _, column := range current.columns {
+		if !targetColumns[strings.ToLower(column.Name)] {
+			changes = append(changes, SchemaChange{
+				Table:       name,
+				Description: "drop column " + name + "." + column.Name,
+				SQL:         alter + "DROP COLUMN " + EscapeID(column.Name, true),
+				Destructive: true,
+			})
+		}
+	}
+
+	currentIndexes := make(map[string]*Index, len(current.indexes))
+	for i := range current.indexes {
+		currentIndexes[strings.ToLower(current.indexes[i].Name)] = &current.indexes[i]
+	}
+	targetIndexes := make(map[string]bool, len(target.indexes))
+	for i := range target.indexes {
+		index := &target.indexes[i]
+		targetIndexes[strings.ToLower(index.Name)] = true
+		definition := indexDefinition(esc, index)
+		existing := currentIndexes[strings.ToLower(index.Name)]
+		if existing != nil && indexDefinition(esc, existing) == definition {
+			continue
+		}
+		if existing != nil {
+			changes = append(changes, dropIndex(name, existing))
+		}
+		changes = append(changes, SchemaChange{
+			Table:       name,
+			Description: "add index " + name + "." + index.Name + ": " + definition,
+			SQL:         alter + "ADD " + definition,
+		})
+	}
+	for i := range current.indexes {
+		if !targetIndexes[strings.ToLower(current.indexes[i].Name)] {
+			changes = append(changes, dropIndex(name, &current.indexes[i]))
+		}
+	}
+	return changes
+}
+
+// diffForeignKeys appends drops of changed and removed keys to drops and
+// additions of new and changed keys to adds
+func diffForeignKeys(table string, current, target []ForeignKey, drops, adds []SchemaChange) ([]SchemaChange, []SchemaChange) {
+	currentKeys := make(map[string]*ForeignKey, len(current))
+	for i := range current {
+		currentKeys[strings.ToLower(current[i].Name)] = &current[i]
+	}
+	targetKeys := make(map[string]bool, len(target))
+	for i := range target {
+		key := &target[i]
+		targetKeys[strings.ToLower(key.Name)] = true
+		definition := foreignKeyDefinition(key)
+		existing := currentKeys[strings.ToLower(key.Name)]
+		if existing != nil && foreignKeyDefinition(existing) == definition {
+			continue
+		}
+		if existing != nil {
+			drops = append(drops, dropForeignKey(table, *existing))
+		}
+		adds = append(adds, SchemaChange{
+			Table:       table,
+			Description: "add foreign key " + table + "." + key.Name + ": " + definition,
+			SQL:         "ALTER TABLE " + EscapeID(table, true) + " ADD " + definition,
+		})
+	}
+	for i := range current {
+		if !targetKeys[strings.ToLower(current[i].Name)] {
+			drops = append(drops, dropForeignKey(table, current[i]))
+		}
+	}
+	return drops, adds
+}
+
+func dropIndex(table string, index *Index) SchemaChange {
+	sql := "ALTER TABLE " + EscapeID(table, true) + " DROP INDEX " + EscapeID(index.Name, true)
+	if index.Name == "PRIMARY" {
+		sql = "ALTER TABLE " + EscapeID(table, true) + " DROP PRIMARY KEY"
+	}
+	return SchemaChange{
+		Table:       table,
+		Description: "drop index " + table + "." + index.Name,
+		SQL:         sql,
+	}
+}
+
+func dropForeignKey(table string, key ForeignKey) SchemaChange {
+	return SchemaChange{
+		Table:       table,
+		Description: "drop foreign key " + table + "." + key.Name,
+		SQL:         "ALTER TABLE " + EscapeID(table, true) + " DROP FOREIGN KEY " + EscapeID(key.Name, true),
+	}
+}
+
+// quote returns string literal of s escaped by esc
+func quote(esc escaper, s string) string {
+	return string(esc.appendString(nil, s))
+}
+
+// charsetOf returns character set of collation
+func charsetOf(collation string) string {
+	if i := strings.IndexByte(collation, '_'); i > 0 {
+		return collation[:i]
+	}
+	return collation
+}
+
+// columnDefinition returns column definition of column like SHOW CREATE TABLE
+func columnDefinition(esc escaper, column *Column) string {
+	var b strings.Builder
+	b.WriteString(EscapeID(column.Name, true))
+	b.WriteByte(' ')
+	b.WriteString(column.Type)
+	if column.CharacterSet != "" {
+		b.WriteString(" CHARACTER SET " + column.CharacterSet + " COLLATE " + column.Collation)
+	}
+	extra := strings.TrimSpace(strings.ReplaceAll(column.Extra, "DEFAULT_GENERATED", ""))
+	if column.Generated != "" {
+		kind := "VIRTUAL"
+		if strings.Contains(strings.ToUpper(extra), "STORED") {
+			kind = "STORED"
+		}
+		b.WriteString(" GENERATED ALWAYS AS (" + column.Generated + ") " + kind)
+		extra = ""
+	}
+	if column.Nullable {
+		b.WriteString(" NULL")
+	} else {
+		b.WriteString(" NOT NULL")
+	}
+	if column.Default != nil && column.Generated == "" {
+		b.WriteString(" DEFAULT ")
+		switch {
+		case strings.HasPrefix(strings.ToUpper(*column.Default), "CURRENT_TIMESTAMP"):
+			b.WriteString(*column.Default)
+		case strings.Contains(column.Extra, "DEFAULT_GENERATED"):
+			// expression default, information_schema drops its brackets
+			b.WriteString("(" + *column.Default + ")")
+		default:
+			b.WriteString(quote(esc, *column.Default))
+		}
+	}
+	if extra != "" {
+		b.WriteString(" " + extra)
+	}
+	if column.Comment != "" {
+		b.WriteString(" COMMENT " + quote(esc, column.Comment))
+	}
+	return b.String()
+}
+
+// indexDefinition returns index definition of ALTER TABLE ADD
+func indexDefinition(esc escaper, index *Index) string {
+	columns := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		columns[i] = EscapeID(column.Name, true)
+		if column.Length > 0 {
+			columns[i] += "(" + strconv.Itoa(column.Length) + ")"
+		}
+	}
+	var kind string
+	switch {
+	case index.Name == "PRIMARY":
+		return "PRIMARY KEY (" + strings.Join(columns, ", ") + ")"
+	case index.Type == "FULLTEXT" || index.Type == "SPATIAL":
+		kind = index.Type + " KEY "
+	case index.Unique:
+		kind = "UNIQUE KEY "
+	default:
+		kind = "KEY "
+	}
+	definition := kind + EscapeID(index.Name, true) + " (" + strings.Join(columns, ", ") + ")"
+	if index.Comment != "" {
+		definition += " COMMENT " + quote(esc, index.Comment)
+	}
+	return definition
+}
+
+// foreignKeyDefinition returns constraint definition of ALTER TABLE ADD
+func foreignKeyDefinition(key *ForeignKey) string {
+	return "CONSTRAINT " + EscapeID(key.Name, true) + " FOREIGN KEY (" + EscapeIDs(key.Columns, true) +
+		") REFERENCES " + EscapeID(key.RefTable, true) + " (" + EscapeIDs(key.RefColumns, true) +
+		") ON DELETE " + key.OnDelete + " ON UPDATE " + key.OnUpdate
+}
+
+// columnTypePattern splits column type: name, length, scale and attributes
+var columnTypePattern = regexp.MustCompile(`^(\w+)(?:\((\d+)(?:,(\d+))?\))?(.*)$`)
+
+// typeRanks widening order of type families
+var typeRanks = map[string][2]int{
+	"tinyint": {0, 1}, "smallint": {0, 2}, "mediumint": {0, 3}, "int": {0, 4}, "bigint": {0, 5},
+	"char": {1, 0}, "varchar": {1, 1}, "tinytext": {1, 2}, "text": {1, 3}, "mediumtext": {1, 4}, "longtext": {1, 5},
+	"binary": {2, 0}, "varbinary": {2, 1}, "tinyblob": {2, 2}, "blob": {2, 3}, "mediumblob": {2, 4}, "longblob": {2, 5},
+	"float": {3, 1}, "double": {3, 2},
+}
+
+// narrows reports whether changing column from to to may lose data: a type
+// that is not a widening of the old one, or NULL becoming NOT NULL
+func narrows(from, to *Column) bool {
+	if from.Nullable && !to.Nullable {
+		return true
+	}
+	if strings.EqualFold(from.Type, to.Type) {
+		return false
+	}
+	f := columnTypePattern.FindStringSubmatch(strings.ToLower(from.Type))
+	t := columnTypePattern.FindStringSubmatch(strings.ToLower(to.Type))
+	if f == nil || t == nil || strings.TrimSpace(f[4]) != strings.TrimSpace(t[4]) {
+		// unsigned and zerofill changes or unknown types
+		return true
+	}
+	fromLength, _ := strconv.Atoi(f[2])
+	toLength, _ := strconv.Atoi(t[2])
+	fromScale, _ := strconv.Atoi(f[3])
+	toScale, _ := strconv.Atoi(t[3])
+	if f[1] == t[1] {
+		switch f[1] {
+		case "decimal":
+			return toLength-toScale < fromLength-fromScale || toScale < fromScale
+		case "char", "varchar", "binary", "varbinary", "bit":
+			return toLength < fromLength
+		}
+		// integer display width
+		return false
+	}
+	fromRank, ok1 := typeRanks[f[1]]
+	toRank, ok2 := typeRanks[t[1]]
+	if !ok1 || !ok2 || fromRank[0] != toRank[0] || toRank[1] < fromRank[1] {
+		return true
+	}
+	// char(n) to varchar(m) needs m >= n, text types hold any varchar
+	return toLength != 0 && toLength < fromLength
+}
@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func schemaScript(tables, columns, indexes [][]driver.Value) []testResult {
+	return []testResult{
+		result("information_schema.TABLES", []string{"name", "type", "engine", "collation"}, tables...),
+		result("information_schema.COLUMNS", []string{"name", "type", "nullable", "default", "character_set", "collation", "extra"}, columns...),
+		result("information_schema.STATISTICS", []string{"name", "unique", "column"}, indexes...),
+		result("information_schema.KEY_COLUMN_USAGE", []string{"name"}),
+		result("SHOW CREATE TABLE", []string{"Table", "Create Table"}, []driver.Value{"posts", "CREATE TABLE `posts` (`id` int) ENGINE=InnoDB AUTO_INCREMENT=7 DEFAULT CHARSET=utf8mb4"}),
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	a, _ := newTestDB(t, schemaScript(
+		[][]driver.Value{{"posts", "BASE TABLE", "InnoDB", "utf8mb4_unicode_ci"}, {"users", "BASE TABLE", "InnoDB", "utf8mb4_unicode_ci"}, {"v", "VIEW", "", ""}},
+		[][]driver.Value{
+			{"id", "int unsigned", int64(0), nil, "", "", "auto_increment"},
+			{"name", "varchar(100)", int64(0), "", "utf8mb4", "utf8mb4_unicode_ci", ""},
+			{"email", "varchar(191)", int64(1), nil, "utf8mb4", "utf8mb4_unicode_ci", ""},
+		},
+		[][]driver.Value{{"PRIMARY", int64(1), "id"}, {"uniq_email", int64(1), "email"}},
+	)...)
+	b, _ := newTestDB(t, schemaScript(
+		[][]driver.Value{{"legacy", "BASE TABLE", "InnoDB", "utf8mb4_unicode_ci"}, {"users", "BASE TABLE", "MyISAM", "utf8mb4_unicode_ci"}},
+		[][]driver.Value{
+			{"id", "int unsigned", int64(0), nil, "", "", "auto_increment"},
+			{"name", "varchar(200)", int64(0), "", "utf8mb4", "utf8mb4_unicode_ci", ""},
+			{"old", "int", int64(1), "0", "", "", ""},
+		},
+		[][]driver.Value{{"PRIMARY", int64(1), "id"}, {"idx_old", int64(0), "old"}},
+	)...)
+
+	diff, err := DiffSchemas(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE `posts` (`id` int) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"ALTER TABLE `users` ENGINE=InnoDB",
+		"ALTER TABLE `users` MODIFY COLUMN `name` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT ''",
+		"ALTER TABLE `users` ADD COLUMN `email` varchar(191) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL AFTER `name`",
+		"ALTER TABLE `users` DROP COLUMN `old`",
+		"ALTER TABLE `users` ADD UNIQUE KEY `uniq_email` (`email`)",
+		"ALTER TABLE `users` DROP INDEX `idx_old`",
+		"DROP TABLE `legacy`",
+	}
+	if got := diff.DDL(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DDL() =\n%q\nwant\n%q", got, want)
+	}
+
+	var destructive []string
+	for _, change := range diff.Destructive() {
+		destructive = append(destructive, change.SQL)
+	}
+	if want := []string{want[2], want[4], want[7]}; !reflect.DeepEqual(destructive, want) {
+		t.Errorf("Destructive() = %q, want %q", destructive, want)
+	}
+	if report := diff.Report(); len(report) == 0 || report[:2] != "  " {
+		t.Errorf("Report() = %q", report)
+	}
+
+	same, err := DiffSchemas(a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(same.Changes) != 0 || same.Report() != "schemas are identical\n" {
+		t.Errorf("DiffSchemas(a, a) = %q", same.DDL())
+	}
+}
+
+func TestNarrows(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"varchar(50)", "varchar(100)", false},
+		{"varchar(100)", "varchar(50)", true},
+		{"int(11)", "int(10)", false},
+		{"int", "bigint", false},
+		{"bigint", "int", true},
+		{"int", "int unsigned", true},
+		{"char(10)", "varchar(10)", false},
+		{"varchar(10)", "text", false},
+		{"text", "varchar(255)", true},
+		{"decimal(10,2)", "decimal(12,2)", false},
+		{"decimal(10,2)", "decimal(10,3)", true},
+		{"int", "varchar(20)", true},
+		{"enum('a','b')", "enum('a')", true},
+	}
+	for _, test := range tests {
+		if got := narrows(&Column{Type: test.from}, &Column{Type: test.to}); got != test.want {
+			t.Errorf("narrows(%s, %s) = %v, want %v", test.from, test.to, got, test.want)
+		}
+	}
+	if !narrows(&Column{Type: "int", Nullable: true}, &Column{Type: "int"}) {
+		t.Error("NULL to NOT NULL is not narrowing")
+	}
+}
+
+func TestColumnDefinition(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		esc    escaper
+		column Column
+		want   string
+	}{
+		{escaper{}, Column{Name: "name", Type: "varchar(10)", Default: str(`it's a\b`), Comment: "it's"}, "`name` varchar(10) NOT NULL DEFAULT 'it\\'s a\\\\b' COMMENT 'it\\'s'"},
+		{escaper{noBackslashEscapes: true}, Column{Name: "name", Type: "varchar(10)", Default: str(`it's a\b`), Comment: "it's"}, "`name` varchar(10) NOT NULL DEFAULT 'it''s a\\b' COMMENT 'it''s'"},
+		{escaper{}, Column{Name: "created", Type: "datetime", Default: str("CURRENT_TIMESTAMP"), Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"}, "`created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP"},
+		{escaper{}, Column{Name: "uuid", Type: "binary(16)", Default: str("uuid_to_bin(uuid())"), Extra: "DEFAULT_GENERATED"}, "`uuid` binary(16) NOT NULL DEFAULT (uuid_to_bin(uuid()))"},
+	}
+	for _, test := range tests {
+		if got := columnDefinition(test.esc, &test.column); got != test.want {
+			t.Errorf("columnDefinition(%s) = %s, want %s", test.column.Name, got, test.want)
+		}
+	}
+}
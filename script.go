@@ -0,0 +1,246 @@
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ScriptStatement statement of a SQL script
+type ScriptStatement struct {
+	SQL   string
+	Line  int // line of the first character, from 1
+	Index int // position in the script, from 0
+}
+
+// ScriptError failure of a script statement
+type ScriptError struct {
+	ScriptStatement
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("mysql: script line %d: %v", e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ScriptScanner splits a SQL script into statements ending with the
+// delimiter, ; by default. Quoted strings and identifiers, comments and
+// DELIMITER lines of the mysql client are honored, line comments are dropped
+// and statements of only /* */ comments are skipped, /*! */ ones are kept
+type ScriptScanner struct {
+	r         *bufio.Reader
+	delimiter string
+	line      int // lines read
+
+	buf       strings.Builder // current statement
+	start     int             // line of current statement, 0 while blank
+	quote     byte            // open quote
+	inComment bool            // inside /* */
+	code      bool            // current statement has more than plain comments
+
+	pending []ScriptStatement
+	index   int
+	stmt    ScriptStatement
+	err     error
+	eof     bool
+}
+
+// NewScriptScanner returns a ScriptScanner reading r
+func NewScriptScanner(r io.Reader) *ScriptScanner {
+	return &ScriptScanner{r: bufio.NewReader(r), delimiter: ";"}
+}
+
+// Scan advances to the next statement, false at the end or on error
+func (s *ScriptScanner) Scan() bool {
+	for len(s.pending) == 0 {
+		if s.eof || s.err != nil {
+			return false
+		}
+		line, err := s.r.ReadString('\n')
+		if err == io.EOF {
+			s.eof = true
+		} else if err != nil {
+			s.err = err
+			return false
+		}
+		if line != "" {
+			s.line++
+			s.scanLine(line)
+		}
+		if s.eof {
+			s.emit()
+		}
+	}
+	s.stmt = s.pending[0]
+	s.pending = s.pending[1:]
+	return true
+}
+
+// Statement returns the statement read by Scan
+func (s *ScriptScanner) Statement() ScriptStatement {
+	return s.stmt
+}
+
+// Err returns the read error stopping Scan
+func (s *ScriptScanner) Err() error {
+	return s.err
+}
+
+// scanLine adds line to the current statement, completed statements are pending
+func (s *ScriptScanner) scanLine(line string) {
+	if s.start == 0 && s.quote == 0 && !s.inComment {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.EqualFold(fields[0], "DELIMITER") {
+			s.delimiter = fields[1]
+			return
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.inComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				s.inComment = false
+				s.buf.WriteString("*/")
+				i++
+				continue
+			}
+		case s.quote != 0:
+			if c == '\\' && s.quote != '`' && i+1 < len(line) {
+				s.buf.WriteByte(c)
+				i++
+				c = line[i]
+			} else if c == s.quote {
+				if i+1 < len(line) && line[i+1] == c {
+					s.buf.WriteByte(c)
+					i++
+				} else {
+					s.quote = 0
+				}
+			}
+		case strings.HasPrefix(line[i:], s.delimiter):
+			s.emit()
+			i += len(s.delimiter) - 1
+			continue
+		case c == '#' || c == '-' && strings.HasPrefix(line[i:], "--") && (i+2 == len(line) || isSpace(line[i+2])):
+			// line comment, keep the newline
+			i = len(line) - 1
+			if line[i] != '\n' {
+				continue
+			}
+			c = '\n'
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			s.inComment = true
+			if i+2 < len(line) && line[i+2] == '!' {
+				// executable comment
+				s.code = true
+			}
+			s.mark()
+			s.buf.WriteString("/*")
+			i++
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			s.quote = c
+		}
+		if !isSpace(c) {
+			s.mark()
+			if !s.inComment {
+				s.code = true
+			}
+		}
+		if s.start != 0 {
+			s.buf.WriteByte(c)
+		}
+	}
+}
+
+// mark records the line of the current statement at its first character
+func (s *ScriptScanner) mark() {
+	if s.start == 0 {
+		s.start = s.line
+	}
+}
+
+// emit queues the current statement unless blank or only comments
+func (s *ScriptScanner) emit() {
+	sql := strings.TrimSpace(s.buf.String())
+	s.buf.Reset()
+	if s.start != 0 && s.code && sql != "" {
+		s.pending = append(s.pending, ScriptStatement{SQL: sql, Line: s.start, Index: s.index})
+		s.index++
+	}
+	s.start = 0
+	s.code = false
+}
+
+// ScriptOption configures ExecScript
+type ScriptOption func(*scriptOptions)
+
+type scriptOptions struct {
+	progress        func(stmt ScriptStatement, err error)
+	continueOnError bool
+}
+
+// WithProgress calls fn after each statement with its error
+func WithProgress(fn func(stmt ScriptStatement, err error)) ScriptOption {
+	return func(o *scriptOptions) {
+		o.progress = fn
+	}
+}
+
+// WithContinueOnError runs the remaining statements after a failure, ExecScript
+// then returns every *ScriptError joined
+func WithContinueOnError() ScriptOption {
+	return func(o *scriptOptions) {
+		o.continueOnError = true
+	}
+}
+
+// ExecScript runs statements of the SQL script r in order on one connection,
+// or in the transaction of a Tx, so session variables and USE carry over.
+// It does not need Config.MultiStatements. Failures are *ScriptError with
+// the line of the statement, the first one stops the script unless
+// WithContinueOnError is given
+func (db *DB) ExecScript(ctx context.Context, r io.Reader, opts ...ScriptOption) error {
+	var o scriptOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	script := db.WithContext(ctx)
+	if db.tx == nil && db.conn == nil {
+		conn, err := db.Conn.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		script = script.WithConn(conn)
+	}
+
+	var errs []error
+	scanner := NewScriptScanner(r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		_, err := script.exec(OpScript, "", stmt.SQL)
+		if o.progress != nil {
+			o.progress(stmt, err)
+		}
+		if err != nil {
+			errs = append(errs, &ScriptError{ScriptStatement: stmt, Err: err})
+			if !o.continueOnError {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScriptScanner(t *testing.T) {
+	script := `-- schema
+CREATE TABLE t (a TEXT); # trailing
+INSERT INTO t VALUES ('a;b', "it\'s;", 'x''y;'), (1); SELECT ` + "`c;d`" + ` FROM t;
+/*!40101 SET NAMES utf8mb4 */;
+/* header */;
+/* a */ /* b */
+;
+DELIMITER $$
+CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW
+BEGIN
+  SET NEW.a = 'x;'; -- ignored ;
+END$$
+DELIMITER ;
+SELECT 1 /* ; */ ;
+/* only a comment */ -- and a line comment
+SELECT 2`
+	want := []ScriptStatement{
+		{SQL: "CREATE TABLE t (a TEXT)", Line: 2, Index: 0},
+		{SQL: `INSERT INTO t VALUES ('a;b', "it\'s;", 'x''y;'), (1)`, Line: 3, Index: 1},
+		{SQL: "SELECT `c;d` FROM t", Line: 3, Index: 2},
+		{SQL: "/*!40101 SET NAMES utf8mb4 */", Line: 4, Index: 3},
+		{SQL: "CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW\nBEGIN\n  SET NEW.a = 'x;'; \nEND", Line: 9, Index: 4},
+		{SQL: "SELECT 1 /* ; */", Line: 14, Index: 5},
+		{SQL: "/* only a comment */ \nSELECT 2", Line: 15, Index: 6},
+	}
+	var got []ScriptStatement
+	scanner := NewScriptScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		got = append(got, scanner.Statement())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statements =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	db, d := newTestDB(t)
+	var progress []int
+	err := db.ExecScript(context.Background(), strings.NewReader("SET @a = 1;\nSELECT @a;\n"), WithProgress(func(stmt ScriptStatement, err error) {
+		progress = append(progress, stmt.Line)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.executed(), []string{"SET @a = 1", "SELECT @a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("executed %q, want %q", got, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress lines = %v, want %v", progress, want)
+	}
+
+	db.Conn.Close()
+	err = db.ExecScript(context.Background(), strings.NewReader("SELECT 1;"))
+	if err == nil {
+		t.Fatal("ExecScript on closed DB succeeded")
+	}
+}
+
+func TestExecScriptError(t *testing.T) {
+	db, d := newTestDB(t)
+	d.setFailing("fail")
+	script := "SELECT 1;\nfail one;\nSELECT 2;\n\nfail two;\n"
+
+	err := db.ExecScript(context.Background(), strings.NewReader(script))
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 2 || scriptErr.SQL != "fail one" {
+		t.Errorf("ExecScript() = %v, want error at line 2", err)
+	}
+	if got := d.executed(); len(got) != 1 {
+		t.Errorf("executed %q after failure", got)
+	}
+
+	err = db.ExecScript(context.Background(), strings.NewReader(script), WithContinueOnError())
+	if err == nil || !strings.Contains(err.Error(), "script line 2:") || !strings.Contains(err.Error(), "script line 5:") {
+		t.Errorf("ExecScript() = %v, want errors at lines 2 and 5", err)
+	}
+	if got, want := d.executed(), []string{"SELECT 1", "SELECT 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("executed %q, want %q", got, want)
+	}
+}
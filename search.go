@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"strings"
+)
+
+// Condition sql condition and its args, can be used as where of Update and Delete
+type Condition struct {
+	SQL  string
+	Args []interface{}
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes % and _ wildcards of val with backslash, the default
+// LIKE escape character, so that val matches literally. Under
+// NO_BACKSLASH_ESCAPES LIKE has no default escape character, Contains,
+// StartsWith and EndsWith work in both modes
+func EscapeLike(val string) string {
+	return likeReplacer.Replace(val)
+}
+
+// likeEscape escape character of conditions, not special in string literals
+// whatever the sql_mode
+const likeEscape = "!"
+
+var likeEscapeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, `%`, likeEscape+`%`, `_`, likeEscape+`_`)
+
+// Contains condition `column` LIKE '%val%' ESCAPE '!'
+func Contains(column, val string) Condition {
+	return like(column, "%"+likeEscapeReplacer.Replace(val)+"%")
+}
+
+// StartsWith condition `column` LIKE 'val%' ESCAPE '!'
+func StartsWith(column, val string) Condition {
+	return like(column, likeEscapeReplacer.Replace(val)+"%")
+}
+
+// EndsWith condition `column` LIKE '%val' ESCAPE '!'
+func EndsWith(column, val string) Condition {
+	return like(column, "%"+likeEscapeReplacer.Replace(val))
+}
+
+func like(column, pattern string) Condition {
+	return Condition{
+		SQL:  EscapeID(column, false) + " LIKE ? ESCAPE '" + likeEscape + "'",
+		Args: []interface{}{pattern},
+	}
+}
+
+// SearchMode full-text search modifier of MATCH ... AGAINST
+type SearchMode int
+
+// Full-text search modes
+const (
+	NaturalLanguageMode SearchMode = iota
+	BooleanMode
+	QueryExpansionMode
+)
+
+func (mode SearchMode) String() string {
+	switch mode {
+	case BooleanMode:
+		return "IN BOOLEAN MODE"
+	case QueryExpansionMode:
+		return "WITH QUERY EXPANSION"
+	}
+	return "IN NATURAL LANGUAGE MODE"
+}
+
+// fullTextOperators boolean mode operators, replaced by spaces
+var fullTextOperators = strings.NewReplacer(
+	"+", " ", "-", " ", ">", " ", "<", " ", "(", " ", ")", " ",
+	"~", " ", "*", " ", `"`, " ", "@", " ",
+)
+
+// EscapeFullText removes boolean mode full-text operators from query,
+// leaving plain search words
+func EscapeFullText(query string) string {
+	return strings.Join(strings.Fields(fullTextOperators.Replace(query)), " ")
+}
+
+// MatchAgainst condition MATCH (`columns`) AGAINST (? mode), operators in
+// query are removed in BooleanMode so user input can not inject search syntax
+func MatchAgainst(columns []string, query string, mode SearchMode) Condition {
+	if mode == BooleanMode {
+		query = EscapeFullText(query)
+	}
+	return Condition{
+		SQL:  "MATCH (" + EscapeIDs(columns, false) + ") AGAINST (? " + mode.String() + ")",
+		Args: []interface{}{query},
+	}
+}
@@ -0,0 +1,35 @@
+package mysql_test
+
+import (
+	"reflect"
+	"testing"
+
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+func TestLike(t *testing.T) {
+	if got, want := mysql.EscapeLike(`100%_a\b`), `100\%\_a\\b`; got != want {
+		t.Errorf("EscapeLike = %s, want %s", got, want)
+	}
+	tests := []struct {
+		cond mysql.Condition
+		want mysql.Condition
+	}{
+		{mysql.Contains("name", `50%\!`), mysql.Condition{SQL: "`name` LIKE ? ESCAPE '!'", Args: []interface{}{`%50!%\!!%`}}},
+		{mysql.StartsWith("u.name", "a_"), mysql.Condition{SQL: "`u`.`name` LIKE ? ESCAPE '!'", Args: []interface{}{`a!_%`}}},
+		{mysql.EndsWith("name", "z"), mysql.Condition{SQL: "`name` LIKE ? ESCAPE '!'", Args: []interface{}{`%z`}}},
+		{
+			mysql.MatchAgainst([]string{"title", "body"}, `+go -"sql" (x*) @3 ~a <b>`, mysql.BooleanMode),
+			mysql.Condition{SQL: "MATCH (`title`, `body`) AGAINST (? IN BOOLEAN MODE)", Args: []interface{}{"go sql x 3 a b"}},
+		},
+		{
+			mysql.MatchAgainst([]string{"title"}, `+go`, mysql.NaturalLanguageMode),
+			mysql.Condition{SQL: "MATCH (`title`) AGAINST (? IN NATURAL LANGUAGE MODE)", Args: []interface{}{"+go"}},
+		},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.cond, test.want) {
+			t.Errorf("got %v, want %v", test.cond, test.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sensitive wraps an arg that slow query logs must not show. Insert and
+// InsertUpdate bind Sensitive row values as args instead of inlining them
+type Sensitive struct {
+	V interface{}
+}
+
+// Value implements driver.Valuer, passes V to the driver
+func (s Sensitive) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(s.V)
+}
+
+// SlowQueryConfig configures NewSlowQueryLogger
+type SlowQueryConfig struct {
+	// Threshold statements running at least Threshold are logged
+	Threshold time.Duration
+	// Handler records are written to, slog.Default() handler if nil
+	Handler slog.Handler
+	// SampleRate fraction of slow statements logged, all if <= 0 or >= 1
+	SampleRate float64
+	// Redact reports whether arg i must be hidden in the rendered statement,
+	// Sensitive args are always redacted
+	Redact func(i int, arg interface{}) bool
+}
+
+// slowQueryLogger logs statements slower than threshold
+type slowQueryLogger struct {
+	config SlowQueryConfig
+	logger *slog.Logger
+}
+
+// NewSlowQueryLogger returns an Interceptor logging slow statements with
+// fingerprint, rendered sql, duration, row count and caller file:line
+func NewSlowQueryLogger(config SlowQueryConfig) Interceptor {
+	handler := config.Handler
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+	return &slowQueryLogger{
+		config: config,
+		logger: slog.New(handler),
+	}
+}
+
+func (l *slowQueryLogger) Before(ctx context.Context, info *QueryInfo) context.Context {
+	return ctx
+}
+
+func (l *slowQueryLogger) After(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+	if info.Duration < l.config.Threshold {
+		return
+	}
+	if rate := l.config.SampleRate; rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("op", string(info.Op)),
+		slog.String("fingerprint", Fingerprint(info.SQL)),
+		slog.String("digest_text", Normalize(info.SQL)),
+		slog.String("sql", l.render(info.esc, info.SQL, info.Args)),
+		slog.Duration("duration", info.Duration),
+		slog.Int64("rows", info.RowsAffected),
+		slog.String("caller", caller()),
+	}
+	if info.Table != "" {
+		attrs = append(attrs, slog.String("table", info.Table))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, slog.LevelWarn, "mysql: slow query", attrs...)
+}
+
+// render replaces placeholders of sqlQuery with args escaped by esc, the
+// escaper of the DB, so the statement runs as is under its sql_mode
+func (l *slowQueryLogger) render(esc escaper, sqlQuery string, args []interface{}) string {
+	if len(args) == 0 {
+		return sqlQuery
+	}
+	indexes := placeholderIndexes(sqlQuery)
+	var buf []byte
+	last := 0
+	for i, index := range indexes {
+		if i >= len(args) {
+			break
+		}
+		buf = append(buf, sqlQuery[last:index]...)
+		last = index + 1
+		if l.redact(i, args[i]) {
+			buf = append(buf, "'[REDACTED]'"...)
+			continue
+		}
+		escaped, err := esc.appendEscape(buf, args[i], false)
+		if err != nil {
+			buf = append(buf, '?')
+			continue
+		}
+		buf = escaped
+	}
+	return string(append(buf, sqlQuery[last:]...))
+}
+
+// redact reports whether arg i is hidden, Sensitive is checked before
+// Redact so it is never escaped through its Value
+func (l *slowQueryLogger) redact(i int, arg interface{}) bool {
+	if _, ok := arg.(Sensitive); ok {
+		return true
+	}
+	return l.config.Redact != nil && l.config.Redact(i, arg)
+}
+
+var pkgPath = reflect.TypeOf(DB{}).PkgPath()
+
+// caller returns file:line of the first caller outside this package, test files count as outside
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPath+".") || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryLogger(t *testing.T) {
+	var out bytes.Buffer
+	db, d := newTestDB(t, anyRows)
+	d.setAffected(3)
+	db.interceptors = []Interceptor{
+		NewSlowQueryLogger(SlowQueryConfig{Handler: slog.NewJSONHandler(&out, nil)}),
+		NewSlowQueryLogger(SlowQueryConfig{Threshold: time.Hour, Handler: slog.NewJSONHandler(&out, nil)}),
+	}
+
+	if _, err := db.Update("users", map[string]interface{}{"name": "it's", "password": Sensitive{"secret"}}, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("logged %d records, want 1: %s", len(lines), out.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := record["sql"], "update `users` set `name`='it\\'s',`password`='[REDACTED]' where `id`=1"; got != want {
+		t.Errorf("sql = %v, want %v", got, want)
+	}
+	if got, want := record["table"], "users"; got != want {
+		t.Errorf("table = %v, want %v", got, want)
+	}
+	if got, want := record["rows"], 3.0; got != want {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if caller, _ := record["caller"].(string); !strings.Contains(caller, "slowLog_test.go:") {
+		t.Errorf("caller = %v, want slowLog_test.go", record["caller"])
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Errorf("sensitive arg logged: %s", lines[0])
+	}
+}
+
+func TestSlowQueryLoggerEscaper(t *testing.T) {
+	var out bytes.Buffer
+	db, _ := newTestDB(t)
+	db.esc = escaper{noBackslashEscapes: true}
+	db.interceptors = []Interceptor{NewSlowQueryLogger(SlowQueryConfig{
+		Handler: slog.NewJSONHandler(&out, nil),
+		Redact:  func(i int, arg interface{}) bool { return i == 2 },
+	})}
+
+	if _, err := db.Query("update users set name = ?, password = ?, pin = ?", `it's\`, Sensitive{"secret"}, 1234); err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := record["sql"], `update users set name = 'it''s\', password = '[REDACTED]', pin = '[REDACTED]'`; got != want {
+		t.Errorf("sql = %v, want %v", got, want)
+	}
+}
+
+func TestSlowQueryLoggerInsert(t *testing.T) {
+	var out bytes.Buffer
+	db, _ := newTestDB(t)
+	db.interceptors = []Interceptor{NewSlowQueryLogger(SlowQueryConfig{Handler: slog.NewJSONHandler(&out, nil)})}
+
+	rows := []interface{}{[]interface{}{"a?", Sensitive{"hunter2"}}}
+	if _, err := db.Insert("users", []string{"name", "password"}, rows); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.InsertUpdate("users", []string{"name", "password"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"insert `users` (`name`, `password`) values ('a?', '[REDACTED]')",
+		"insert `users` (`name`, `password`) values ('a?', '[REDACTED]') ON DUPLICATE KEY UPDATE `name`=values(`name`), `password`=values(`password`)",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("logged %d records, want %d: %s", len(lines), len(want), out.String())
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["sql"] != want[i] {
+			t.Errorf("sql = %v, want %v", record["sql"], want[i])
+		}
+		if strings.Contains(line, "hunter2") {
+			t.Errorf("sensitive value logged: %s", line)
+		}
+	}
+}
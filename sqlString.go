@@ -1,74 +1,164 @@
 package mysql
 
 import (
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"sort"
 	"strconv"
-	"strings"
+	"time"
 )
 
 // https://github.com/mysqljs/sqlstring/blob/master/lib/SqlString.js
 
-var idGlobalReplacer = strings.NewReplacer("`", "``")
-var qualGlobalReplacer = strings.NewReplacer(".", "`.`")
-var slashGlobalReplacer = strings.NewReplacer(`\`, `\\`)
-var charsGlobalReplacer = strings.NewReplacer("\x00", `\0`, "\b", `\b`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`, `"`, `\"`, `'`, `\'`)
-
 // EscapeID escapes mysql field
 func EscapeID(val string, forbidQualified bool) string {
-	if forbidQualified {
-		return "`" + idGlobalReplacer.Replace(val) + "`"
+	return string(AppendEscapeID(make([]byte, 0, len(val)+2), val, forbidQualified))
+}
+
+// AppendEscapeID appends escaped mysql field to buf
+func AppendEscapeID(buf []byte, val string, forbidQualified bool) []byte {
+	buf = append(buf, '`')
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; {
+		case c == '`':
+			buf = append(buf, '`', '`')
+		case c == '.' && !forbidQualified:
+			buf = append(buf, '`', '.', '`')
+		default:
+			buf = append(buf, c)
+		}
 	}
-	return "`" + qualGlobalReplacer.Replace(idGlobalReplacer.Replace(val)) + "`"
+	return append(buf, '`')
 }
 
 // EscapeIDs escapes mysql fields
 func EscapeIDs(val []string, forbidQualified bool) string {
-	var sql = ""
+	return string(AppendEscapeIDs(nil, val, forbidQualified))
+}
 
+// AppendEscapeIDs appends escaped mysql fields to buf
+func AppendEscapeIDs(buf []byte, val []string, forbidQualified bool) []byte {
 	for i, v := range val {
 		if i != 0 {
-			sql += `, `
+			buf = append(buf, ", "...)
 		}
-		sql += EscapeID(v, forbidQualified)
+		buf = AppendEscapeID(buf, v, forbidQualified)
 	}
-	return sql
+	return buf
 }
 
 /* Danger functions */
 
+// escaper escapes values according to server sql_mode
+type escaper struct {
+	// noBackslashEscapes NO_BACKSLASH_ESCAPES is in effect, quotes are doubled instead
+	noBackslashEscapes bool
+	// loc time.Time values are converted to, UTC if nil
+	loc *time.Location
+	// bound Sensitive values are appended to when not nil, their literal
+	// is a ? placeholder so the statement text never holds them
+	bound *[]interface{}
+}
+
+// defaultEscaper used by package level functions, assumes backslash escapes and UTC
+var defaultEscaper = escaper{}
+
 // Escape escapes mysql value
-func Escape(val interface{}, stringifyObjects bool) (str string, err error) {
+func Escape(val interface{}, stringifyObjects bool) (string, error) {
+	return defaultEscaper.escape(val, stringifyObjects)
+}
+
+// AppendEscape appends escaped mysql value to buf and returns the extended buffer
+func AppendEscape(buf []byte, val interface{}) ([]byte, error) {
+	return defaultEscaper.appendEscape(buf, val, false)
+}
+
+func (e escaper) escape(val interface{}, stringifyObjects bool) (string, error) {
+	buf, err := e.appendEscape(nil, val, stringifyObjects)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (e escaper) appendEscape(buf []byte, val interface{}, stringifyObjects bool) (ret []byte, err error) {
 	defer func() {
 		err2 := recover()
 		if err == nil && err2 != nil {
-			err = fmt.Errorf("Panic: %v\r\n%s", err2, debug.Stack())
+			ret, err = nil, fmt.Errorf("Panic: %v\r\n%s", err2, debug.Stack())
 		}
 	}()
+	return e.appendValue(buf, val, stringifyObjects)
+}
 
+func (e escaper) appendValue(buf []byte, val interface{}, stringifyObjects bool) ([]byte, error) {
 	// Check nil
 	if val == nil {
-		return "NULL", nil
+		return append(buf, "NULL"...), nil
+	}
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return append(buf, "NULL"...), nil
+	}
+
+	if j, ok := val.(JSONValue); ok {
+		return e.appendJSON(buf, j)
+	}
+	if s, ok := val.(Sensitive); ok && e.bound != nil {
+		*e.bound = append(*e.bound, s)
+		return append(buf, '?'), nil
+	}
+
+	// driver.Valuer, sql.Null* are NULL or their value
+	if valuer, ok := val.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		return e.appendValue(buf, value, stringifyObjects)
+	}
+
+	// check time, before fmt.Stringer
+	switch v := val.(type) {
+	case time.Time:
+		return e.appendTime(buf, v), nil
+	case *time.Time:
+		return e.appendTime(buf, *v), nil
+	case Binary:
+		return e.appendBytes(append(buf, "_binary"...), v), nil
+	}
+
+	// []byte, Hex and byte arrays are hex literals, binary safe in any charset
+	if b, ok := asBytes(val); ok {
+		buf = append(buf, "X'"...)
+		pos := len(buf)
+		buf = reserveBuffer(buf, hex.EncodedLen(len(b)))
+		hex.Encode(buf[pos:], b)
+		return append(buf, '\''), nil
 	}
 
 	// force escape string
 	if stringifyObjects {
+		if _, ok := val.(fmt.Stringer); !ok {
+			if quoted, ok := appendNumber(append(buf, '\''), reflect.ValueOf(val)); ok {
+				return append(quoted, '\''), nil
+			}
+		}
 		valStr, err := asString(val)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return escapeString(valStr), nil
+		return e.appendString(buf, valStr), nil
 	}
 
 	// check string
 	switch v := val.(type) {
 	case string:
-		return escapeString(v), nil
-	case []byte:
-		return escapeString(string(v)), nil
+		return e.appendString(buf, v), nil
 	case fmt.Stringer:
-		return escapeString(v.String()), nil
+		return e.appendString(buf, v.String()), nil
 	}
 
 	// check pointer
@@ -82,121 +172,174 @@ func Escape(val interface{}, stringifyObjects bool) (str string, err error) {
 	switch rvKind {
 	// array
 	case reflect.Array, reflect.Slice:
-		return arrayToList(rv)
+		return e.appendList(buf, rv)
 	// map
 	case reflect.Struct, reflect.Map:
-		return objectToValues(rv)
+		return e.appendValues(buf, rv)
+	}
 	// other
+	if buf, ok := appendNumber(buf, rv); ok {
+		return buf, nil
+	}
+	return nil, fmt.Errorf("can not escape %v", val)
+}
+
+// appendNumber appends numbers and bools, ok is false for other kinds
+func appendNumber(buf []byte, rv reflect.Value) ([]byte, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return strconv.FormatInt(rv.Int(), 10), nil
+		return strconv.AppendInt(buf, rv.Int(), 10), true
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return strconv.FormatUint(rv.Uint(), 10), nil
+		return strconv.AppendUint(buf, rv.Uint(), 10), true
 	case reflect.Float64:
-		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+		return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 64), true
 	case reflect.Float32:
-		return strconv.FormatFloat(rv.Float(), 'g', -1, 32), nil
+		return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 32), true
 	case reflect.Bool:
-		return strconv.FormatBool(rv.Bool()), nil
+		return strconv.AppendBool(buf, rv.Bool()), true
 	}
-	return "", fmt.Errorf("can not escape %v", val)
+	return buf, false
 }
 
-func arrayToList(array reflect.Value) (string, error) {
-	var sql = ""
-
+// appendList appends array as list, nested arrays are grouped in brackets
+func (e escaper) appendList(buf []byte, array reflect.Value) ([]byte, error) {
+	var err error
 	for i := 0; i < array.Len(); i++ {
 		val := array.Index(i)
 		valType := val.Kind()
-		for valType == reflect.Interface || valType == reflect.Ptr {
+		for (valType == reflect.Interface || valType == reflect.Ptr) && !val.IsNil() {
 			val = val.Elem()
 			valType = val.Kind()
 		}
 		if i != 0 {
-			sql += `, `
+			buf = append(buf, ", "...)
 		}
-		if valType == reflect.Slice || valType == reflect.Array {
-			valStr, err := arrayToList(val)
+		if (valType == reflect.Slice || valType == reflect.Array) && val.Type().Elem().Kind() != reflect.Uint8 {
+			buf, err = e.appendList(append(buf, '('), val)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			sql += `(` + valStr + `)`
+			buf = append(buf, ')')
 		} else {
-			valStr, err := Escape(val.Interface(), true)
+			buf, err = e.appendValue(buf, val.Interface(), true)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			sql += valStr
 		}
 	}
-
-	return sql, nil
+	return buf, nil
 }
 
-func objectToValues(object reflect.Value) (string, error) {
-	var sql = ""
-
+// appendValues appends map or struct as `key`=value list
+func (e escaper) appendValues(buf []byte, object reflect.Value) ([]byte, error) {
 	objectKind := object.Kind()
 	for objectKind == reflect.Interface || objectKind == reflect.Ptr {
 		object = object.Elem()
 		objectKind = object.Kind()
 	}
 
+	var err error
 	switch objectKind {
 	case reflect.Map:
-		for i, key := range object.MapKeys() {
+		keys, keyStrs, err := sortedMapKeys(object)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
 			if i != 0 {
-				sql += `, `
-			}
-
-			val := object.MapIndex(key)
-			xType := val.Kind()
-			for xType == reflect.Ptr || xType == reflect.Interface {
-				val = val.Elem()
-			}
-
-			valStr, err := Escape(val.Interface(), true)
-			if err != nil {
-				return "", err
-			}
-			xType = key.Kind()
-			for xType == reflect.Ptr || xType == reflect.Interface {
-				key = key.Elem()
+				buf = append(buf, ", "...)
 			}
-			keyStr, err := asString(key.Interface())
+			buf = append(AppendEscapeID(buf, keyStrs[i], false), '=')
+			buf, err = e.appendValue(buf, object.MapIndex(key).Interface(), true)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			sql += EscapeID(keyStr, false) + "=" + valStr
 		}
 	case reflect.Struct:
-		structKeys := object.Type()
-		for i := 0; i < object.NumField(); i++ {
-			val := object.Field(i)
-			if !val.CanInterface() {
+		first := true
+		for _, field := range structFields(object.Type()) {
+			if field.readOnly {
 				continue
 			}
-			if i != 0 {
-				sql += `, `
+			val, ok := fieldByIndex(object, field.index)
+			if !ok || field.omitEmpty && val.IsZero() {
+				continue
+			}
+			if !first {
+				buf = append(buf, ", "...)
 			}
-			xType := val.Kind()
-			for xType == reflect.Ptr || xType == reflect.Interface {
-				val = val.Elem()
+			first = false
+			buf = append(AppendEscapeID(buf, field.name, false), '=')
+			if field.json {
+				buf, err = e.appendJSON(buf, JSON(val.Interface()))
+			} else {
+				buf, err = e.appendValue(buf, val.Interface(), true)
 			}
-			valStr, err := Escape(val.Interface(), true)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			sql += EscapeID(structKeys.Field(i).Name, false) + "=" + valStr
 		}
 	}
-
-	return sql, nil
+	return buf, nil
 }
 
 /* Danger functions */
 
-func escapeString(val string) string {
-	return `'` + charsGlobalReplacer.Replace(slashGlobalReplacer.Replace(val)) + `'`
+// appendTime appends t as DATETIME(6) literal in e.loc, the zero time is
+// NULL since the zero date is rejected by strict sql_mode (NO_ZERO_DATE)
+func (e escaper) appendTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(buf, "NULL"...)
+	}
+	loc := e.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	buf = append(buf, '\'')
+	buf = t.In(loc).AppendFormat(buf, "2006-01-02 15:04:05.000000")
+	return append(buf, '\'')
+}
+
+// appendBytes appends quoted and escaped v to buf
+func (e escaper) appendBytes(buf, v []byte) []byte {
+	buf = append(buf, '\'')
+	if e.noBackslashEscapes {
+		buf = EscapeBytesQuotes(buf, v)
+	} else {
+		buf = EscapeBytesBackslash(buf, v)
+	}
+	return append(buf, '\'')
+}
+
+// appendString appends quoted and escaped v to buf
+func (e escaper) appendString(buf []byte, v string) []byte {
+	buf = append(buf, '\'')
+	if e.noBackslashEscapes {
+		buf = EscapeStringQuotes(buf, v)
+	} else {
+		buf = EscapeStringBackslash(buf, v)
+	}
+	return append(buf, '\'')
+}
+
+// asBytes returns content of byte slices and arrays
+func asBytes(val interface{}) ([]byte, bool) {
+	if b, ok := val.([]byte); ok {
+		return b, true
+	}
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	b := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(b), rv)
+	return b, true
 }
 
 // MySQL package
@@ -351,7 +494,13 @@ func EscapeStringQuotes(buf []byte, v string) []byte {
 }
 
 // BuildFieldValue build fiels and values from struct or map, prepare = "=?" or ""
+// struct fields follow `db` tags, see TagName
 func BuildFieldValue(data interface{}, prepare string) (fields []string, values []interface{}, err error) {
+	return buildFieldValue(data, prepare, false)
+}
+
+// buildFieldValue is BuildFieldValue, write excludes readonly fields
+func buildFieldValue(data interface{}, prepare string, write bool) (fields []string, values []interface{}, err error) {
 	defer func() {
 		err2 := recover()
 		if err == nil && err2 != nil {
@@ -367,28 +516,76 @@ func BuildFieldValue(data interface{}, prepare string) (fields []string, values
 
 	switch valueType {
 	case reflect.Map:
-		for _, key := range value.MapKeys() {
-			keyStr, err := asString(key.Interface())
-			if err != nil {
-				return nil, nil, err
-			}
-			fields = append(fields, EscapeID(keyStr, true)+prepare)
+		keys, keyStrs, err := sortedMapKeys(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, key := range keys {
+			fields = append(fields, EscapeID(keyStrs[i], true)+prepare)
 			values = append(values, value.MapIndex(key).Interface())
 		}
 	case reflect.Struct:
-		structKeys := value.Type()
-		for i := 0; i < value.NumField(); i++ {
-			val := value.Field(i)
-			if !val.CanInterface() {
+		for _, field := range structFields(value.Type()) {
+			if write && field.readOnly {
+				continue
+			}
+			val, ok := fieldByIndex(value, field.index)
+			if !ok || field.omitEmpty && val.IsZero() {
 				continue
 			}
-			fields = append(fields, EscapeID(structKeys.Field(i).Name, true)+prepare)
-			values = append(values, val.Interface())
+			fields = append(fields, EscapeID(field.name, true)+prepare)
+			if field.json {
+				values = append(values, JSON(val.Interface()))
+			} else {
+				values = append(values, val.Interface())
+			}
 		}
 	} // switch
 	return
 }
 
+// SortedKeys returns keys of map m as strings in the order used to build
+// fields and values from maps: sorted by their string form, byte-wise ascending.
+// Generated SQL from the same map is therefore always identical
+func SortedKeys(m interface{}) ([]string, error) {
+	value := reflect.ValueOf(m)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Map {
+		return nil, fmt.Errorf("can not get keys of %v", m)
+	}
+	_, keyStrs, err := sortedMapKeys(value)
+	return keyStrs, err
+}
+
+// sortedMapKeys returns map keys and their string form sorted by the string form
+func sortedMapKeys(m reflect.Value) ([]reflect.Value, []string, error) {
+	keys := m.MapKeys()
+	keyStrs := make([]string, len(keys))
+	for i, key := range keys {
+		keyStr, err := asString(key.Interface())
+		if err != nil {
+			return nil, nil, err
+		}
+		keyStrs[i] = keyStr
+	}
+	sort.Sort(mapKeys{keys, keyStrs})
+	return keys, keyStrs, nil
+}
+
+type mapKeys struct {
+	keys    []reflect.Value
+	keyStrs []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, j int) bool { return m.keyStrs[i] < m.keyStrs[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.keyStrs[i], m.keyStrs[j] = m.keyStrs[j], m.keyStrs[i]
+}
+
 func asString(src interface{}) (string, error) {
 	if src == nil {
 		return "NULL", nil
@@ -0,0 +1,217 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	mysql "github.com/vinhjaxt/mysql-go"
+)
+
+func TestMapOrdering(t *testing.T) {
+	data := map[string]interface{}{"name": "Vinh", "data": nil, "id": 3, "Zone": true}
+
+	keys, err := mysql.SortedKeys(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Zone", "data", "id", "name"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("SortedKeys = %v, want %v", keys, want)
+	}
+
+	for i := 0; i < 10; i++ {
+		fields, values, err := mysql.BuildFieldValue(data, "=?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(fields, ","), "`Zone`=?,`data`=?,`id`=?,`name`=?"; got != want {
+			t.Fatalf("BuildFieldValue fields = %s, want %s", got, want)
+		}
+		if want := []interface{}{true, nil, 3, "Vinh"}; !reflect.DeepEqual(values, want) {
+			t.Fatalf("BuildFieldValue values = %v, want %v", values, want)
+		}
+
+		escaped, err := mysql.Escape(data, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "`Zone`='true', `data`=NULL, `id`='3', `name`='Vinh'"; escaped != want {
+			t.Fatalf("Escape = %s, want %s", escaped, want)
+		}
+	}
+}
+
+type testBase struct {
+	ID      int    `db:"id,readonly"`
+	Created string `db:"created_at,omitempty"`
+}
+
+type testUser struct {
+	testBase
+	Name   string `db:"name"`
+	Data   *string
+	Secret string `db:"-"`
+	secret string
+}
+
+func TestStructTags(t *testing.T) {
+	user := testUser{testBase: testBase{ID: 3}, Name: "Vinh", Secret: "x", secret: "y"}
+
+	fields, values, err := mysql.BuildFieldValue(user, "=?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(fields, ","), "`id`=?,`name`=?,`Data`=?"; got != want {
+		t.Errorf("BuildFieldValue fields = %s, want %s", got, want)
+	}
+	if len(values) != 3 || values[0] != 3 || values[1] != "Vinh" {
+		t.Errorf("BuildFieldValue values = %v", values)
+	}
+
+	escaped, err := mysql.Escape(&user, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "`name`='Vinh', `Data`=NULL"; escaped != want {
+		t.Errorf("Escape = %s, want %s", escaped, want)
+	}
+
+	user.Created = "2020-07-15"
+	escaped, err = mysql.Escape(user, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "`created_at`='2020-07-15', `name`='Vinh', `Data`=NULL"; escaped != want {
+		t.Errorf("Escape = %s, want %s", escaped, want)
+	}
+}
+
+func TestEscapeValuer(t *testing.T) {
+	date := time.Date(2020, 7, 15, 10, 20, 30, 123456000, time.FixedZone("ICT", 7*3600))
+	var nilTime *time.Time
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{date, "'2020-07-15 03:20:30.123456'"},
+		{&date, "'2020-07-15 03:20:30.123456'"},
+		{time.Time{}, "NULL"},
+		{[]interface{}{time.Time{}, &time.Time{}}, "NULL, NULL"},
+		{sql.NullInt64{Int64: 5, Valid: true}, "5"},
+		{sql.NullInt64{}, "NULL"},
+		{sql.NullString{String: "it's", Valid: true}, `'it\'s'`},
+		{sql.NullTime{Time: date, Valid: true}, "'2020-07-15 03:20:30.123456'"},
+		{[]interface{}{sql.NullString{}, nilTime, date}, "NULL, NULL, '2020-07-15 03:20:30.123456'"},
+	}
+	for _, test := range tests {
+		got, err := mysql.Escape(test.val, false)
+		if err != nil || got != test.want {
+			t.Errorf("Escape(%v) = %s %v, want %s", test.val, got, err, test.want)
+		}
+	}
+}
+
+func TestEscapeBinary(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{[]byte{0xff, 0x00, '\''}, "X'ff0027'"},
+		{[]byte{}, "X''"},
+		{mysql.Hex("\xbf'"), "X'bf27'"},
+		{[2]byte{1, 2}, "X'0102'"},
+		{mysql.Binary("a'\x00"), `_binary'a\'\0'`},
+		{[]interface{}{[]byte("a"), "b"}, "X'61', 'b'"},
+		{[]interface{}{[]interface{}{[]byte("a"), 1}}, "(X'61', '1')"},
+	}
+	for _, test := range tests {
+		got, err := mysql.Escape(test.val, false)
+		if err != nil || got != test.want {
+			t.Errorf("Escape(%v) = %s %v, want %s", test.val, got, err, test.want)
+		}
+	}
+}
+
+func benchRows() []interface{} {
+	rows := make([]interface{}, 1000)
+	for i := range rows {
+		rows[i] = []interface{}{i, "Vịnh's name", 3.14, nil, []byte("data\x00")}
+	}
+	return rows
+}
+
+func BenchmarkEscapeRows(b *testing.B) {
+	rows := benchRows()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := mysql.Escape(rows, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendEscapeRows(b *testing.B) {
+	rows := benchRows()
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		buf, err = mysql.AppendEscape(buf[:0], rows)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEscapeIDs(b *testing.B) {
+	columns := []string{"id", "name", "data", "created_at", "updated_at", "db.table"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		mysql.EscapeIDs(columns, false)
+	}
+}
+
+func TestAppendEscape(t *testing.T) {
+	buf := []byte("insert `t` values ")
+	buf, err := mysql.AppendEscape(buf, []interface{}{[]interface{}{1, time.Second, "a\tb"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "insert `t` values ('1', '1s', 'a\tb')"; string(buf) != want {
+		t.Errorf("AppendEscape = %s, want %s", buf, want)
+	}
+	if got, want := string(mysql.AppendEscapeID(nil, "db.ta`ble", false)), "`db`.`ta``ble`"; got != want {
+		t.Errorf("AppendEscapeID = %s, want %s", got, want)
+	}
+}
+
+func TestEscapeJSON(t *testing.T) {
+	meta := map[string]interface{}{"name": "Vịnh's", "tags": []string{"<a>"}}
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{mysql.JSON(meta), `'{\"name\":\"Vịnh\'s\",\"tags\":[\"<a>\"]}'`},
+		{mysql.CastJSON([]int{1, 2}), `CAST('[1,2]' AS JSON)`},
+		{[]interface{}{[]interface{}{1, mysql.JSON(nil)}}, `('1', 'null')`},
+		{struct {
+			Meta map[string]int `db:"meta,json"`
+		}{map[string]int{"a": 1}}, "`meta`='{\\\"a\\\":1}'"},
+	}
+	for _, test := range tests {
+		got, err := mysql.Escape(test.val, false)
+		if err != nil || got != test.want {
+			t.Errorf("Escape(%v) = %s %v, want %s", test.val, got, err, test.want)
+		}
+	}
+
+	var scanned map[string]interface{}
+	if err := mysql.JSON(&scanned).Scan([]byte(`{"a":[1]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, map[string]interface{}{"a": []interface{}{1.0}}) {
+		t.Errorf("Scan = %v", scanned)
+	}
+}
@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+)
+
+// stmtCache LRU cache of prepared statements keyed by sql text
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // callers currently using stmt
+	evicted bool // close stmt when refs drops to 0
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// prepare returns cached statement for query, preparing it on conn if needed.
+// release must be called when done with the statement
+func (c *stmtCache) prepare(conn *sql.DB, query string) (stmt *sql.Stmt, release func(), err error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[query]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		return entry.stmt, c.releaser(entry), nil
+	}
+	c.mu.Unlock()
+
+	stmt, err = conn.Prepare(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[query]; ok {
+		// prepared concurrently by another caller
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		stmt.Close()
+		return entry.stmt, c.releaser(entry), nil
+	}
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+	var closing []*sql.Stmt
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		closing = append(closing, c.evict(oldest)...)
+	}
+	c.mu.Unlock()
+
+	for _, s := range closing {
+		s.Close()
+	}
+	return stmt, c.releaser(entry), nil
+}
+
+// evict removes elem from cache, returns its statement if no one is using it.
+// c.mu must be held
+func (c *stmtCache) evict(elem *list.Element) []*sql.Stmt {
+	entry := c.lru.Remove(elem).(*stmtEntry)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		return []*sql.Stmt{entry.stmt}
+	}
+	return nil
+}
+
+func (c *stmtCache) releaser(entry *stmtEntry) func() {
+	return func() {
+		c.mu.Lock()
+		entry.refs--
+		closeStmt := entry.evicted && entry.refs == 0
+		c.mu.Unlock()
+		if closeStmt {
+			entry.stmt.Close()
+		}
+	}
+}
+
+// close evicts and closes all cached statements
+func (c *stmtCache) close() error {
+	c.mu.Lock()
+	var closing []*sql.Stmt
+	for c.lru.Len() > 0 {
+		closing = append(closing, c.evict(c.lru.Back())...)
+	}
+	c.mu.Unlock()
+
+	var err error
+	for _, s := range closing {
+		if err2 := s.Close(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
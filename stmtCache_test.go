@@ -0,0 +1,57 @@
+package mysql
+
+import "testing"
+
+func TestStmtCache(t *testing.T) {
+	db, d := newTestDB(t)
+	conn := db.Conn
+	conn.SetMaxOpenConns(1)
+
+	cache := newStmtCache(2)
+	use := func(query string) {
+		stmt, release, err := cache.prepare(conn, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if _, err := stmt.Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	use("a")
+	use("b")
+	use("a") // hit
+	if n, _ := d.statements(); n != 2 {
+		t.Errorf("prepared %d statements, want 2", n)
+	}
+	use("c") // evicts b
+	if _, n := d.statements(); n != 1 {
+		t.Errorf("closed %d statements after eviction, want 1", n)
+	}
+	use("a") // still cached
+	if n, _ := d.statements(); n != 3 {
+		t.Errorf("prepared %d statements, want 3", n)
+	}
+
+	// evicted while in use is closed on release
+	stmt, release, err := cache.prepare(conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	use("d")
+	use("e")
+	_, closed := d.statements()
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, n := d.statements(); n != closed+1 {
+		t.Errorf("closed %d statements after release, want %d", n, closed+1)
+	}
+
+	cache.close()
+	if p, c := d.statements(); p != c {
+		t.Errorf("prepared %d statements but closed %d", p, c)
+	}
+}
@@ -0,0 +1,204 @@
+package mysql
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagName struct tag holding column name and options:
+//
+//	ID      int    `db:"id,readonly"`      // column id, never written
+//	Name    string `db:"name,omitempty"`   // skipped when zero value
+//	Meta    Meta   `db:"meta,json"`        // stored and scanned as JSON
+//	Secret  string `db:"-"`                // ignored
+//	Base                                   // embedded struct fields are flattened
+//
+// See SyncTable for table definition options
+const TagName = "db"
+
+// structField struct field mapped to a column
+type structField struct {
+	name      string // column name
+	index     []int  // index sequence for reflect.Value.FieldByIndex
+	omitEmpty bool   // skip zero value
+	readOnly  bool   // exclude from writes
+	json      bool   // JSON encoded column
+	tagged    bool   // name given by tag
+	opts      string // raw options, DDL ones are read by SyncTable
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns column mapped fields of struct type t in field order.
+// Like Go's selectors (and encoding/json), a shallower field hides promoted
+// ones of the same name, and names left ambiguous at the same depth are
+// dropped unless exactly one of them is tagged
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields := dominantFields(appendStructFields(nil, t, nil, map[reflect.Type]bool{t: true}))
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+// appendStructFields collects every field of t and its embedded structs,
+// parents are the embedded types being walked, which are not walked again
+func appendStructFields(fields []structField, t reflect.Type, index []int, parents map[reflect.Type]bool) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.IndexByte(tag, ','); comma >= 0 {
+			name, opts = tag[:comma], tag[comma+1:]
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if sf.PkgPath != "" && sf.Type.Kind() == reflect.Ptr {
+					// nil pointer to unexported struct cannot be allocated
+					continue
+				}
+				if !parents[ft] {
+					parents[ft] = true
+					fields = appendStructFields(fields, ft, fieldIndex, parents)
+					delete(parents, ft)
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		field := structField{
+			name:   name,
+			index:  fieldIndex,
+			tagged: name != "",
+			opts:   opts,
+		}
+		if name == "" {
+			field.name = sf.Name
+		}
+		for _, opt := range splitOptions(opts) {
+			switch strings.TrimSpace(opt) {
+			case "omitempty":
+				field.omitEmpty = true
+			case "readonly":
+				field.readOnly = true
+			case "json":
+				field.json = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// splitOptions splits tag options on commas outside brackets and quotes,
+// so values such as type=DECIMAL(10,2) or default='a,b' stay whole
+func splitOptions(opts string) []string {
+	var split []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i < len(opts); i++ {
+		c := opts[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			split = append(split, opts[start:i])
+			start = i + 1
+		}
+	}
+	return append(split, opts[start:])
+}
+
+// dominantFields keeps the field each name resolves to, in field order
+func dominantFields(fields []structField) []structField {
+	byName := make(map[string][]int) // name: indexes in fields
+	for i, field := range fields {
+		byName[field.name] = append(byName[field.name], i)
+	}
+	keep := make(map[int]bool, len(byName))
+	for _, candidates := range byName {
+		depth := len(fields[candidates[0]].index)
+		for _, i := range candidates {
+			if d := len(fields[i].index); d < depth {
+				depth = d
+			}
+		}
+		var shallowest, tagged []int
+		for _, i := range candidates {
+			if len(fields[i].index) == depth {
+				shallowest = append(shallowest, i)
+				if fields[i].tagged {
+					tagged = append(tagged, i)
+				}
+			}
+		}
+		switch {
+		case len(shallowest) == 1:
+			keep[shallowest[0]] = true
+		case len(tagged) == 1:
+			keep[tagged[0]] = true
+		}
+	}
+	kept := make([]structField, 0, len(keep))
+	for i, field := range fields {
+		if keep[i] {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// fieldByIndex returns field of struct v, ok is false when an embedded pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanInterface()
+}
+
+// fieldByIndexAlloc returns settable field of struct v, allocating nil embedded pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+type auditFields struct {
+	Created string `db:"created"`
+	Updated string `db:"updated"`
+	Name    string `db:"name"`
+}
+
+type ownerFields struct {
+	Name  string `db:"name"`
+	Owner string `db:"owner"`
+}
+
+type labelFields struct {
+	Label string
+}
+
+type otherLabelFields struct {
+	Label string
+}
+
+type taggedLabelFields struct {
+	Label string `db:"Label"`
+}
+
+type CyclicFields struct {
+	*CyclicFields
+	ID int `db:"id"`
+}
+
+func fieldNames(t reflect.Type) []string {
+	var names []string
+	for _, field := range structFields(t) {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+func TestStructFieldsDominance(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		model interface{}
+		want  []string
+	}{
+		{
+			// both embedded at depth 1 define name: ambiguous, dropped
+			name: "ambiguous",
+			model: struct {
+				auditFields
+				ownerFields
+			}{},
+			want: []string{"created", "updated", "owner"},
+		},
+		{
+			// shallower field wins and keeps its own position
+			name: "shallower",
+			model: struct {
+				ID int `db:"id"`
+				auditFields
+				Name string `db:"name"`
+			}{},
+			want: []string{"id", "created", "updated", "name"},
+		},
+		{
+			name: "untagged ambiguous",
+			model: struct {
+				labelFields
+				otherLabelFields
+			}{},
+			want: nil,
+		},
+		{
+			name: "tagged wins",
+			model: struct {
+				labelFields
+				taggedLabelFields
+			}{},
+			want: []string{"Label"},
+		},
+		{
+			name: "unexported pointer",
+			model: struct {
+				*auditFields
+				ID int `db:"id"`
+			}{},
+			want: []string{"id"},
+		},
+		{
+			name:  "cycle",
+			model: CyclicFields{},
+			want:  []string{"id"},
+		},
+	} {
+		if got := fieldNames(reflect.TypeOf(test.model)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: fields = %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	shallower := structFields(reflect.TypeOf(struct {
+		auditFields
+		Name string `db:"name"`
+	}{}))
+	if index := shallower[len(shallower)-1].index; !reflect.DeepEqual(index, []int{1}) {
+		t.Errorf("name index = %v, want [1]", index)
+	}
+}
@@ -0,0 +1,309 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column definition of a struct field
+type columnDef struct {
+	name          string
+	sqlType       string
+	nullable      bool
+	autoIncrement bool
+	def           string // DEFAULT expression
+	charset       string
+}
+
+// indexDef index of struct fields
+type indexDef struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// tableDef table definition of a struct
+type tableDef struct {
+	columns    []columnDef
+	primaryKey []string
+	indexes    []indexDef
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	jsonValueType = reflect.TypeOf(JSONValue{})
+	byteSliceType = reflect.TypeOf([]byte(nil))
+)
+
+// nullTypes column types of sql.Null* types
+var nullTypes = map[reflect.Type]string{
+	reflect.TypeOf(sql.NullString{}):  "VARCHAR(255)",
+	reflect.TypeOf(sql.NullInt64{}):   "BIGINT",
+	reflect.TypeOf(sql.NullInt32{}):   "INT",
+	reflect.TypeOf(sql.NullInt16{}):   "SMALLINT",
+	reflect.TypeOf(sql.NullByte{}):    "TINYINT UNSIGNED",
+	reflect.TypeOf(sql.NullFloat64{}): "DOUBLE",
+	reflect.TypeOf(sql.NullBool{}):    "TINYINT(1)",
+	reflect.TypeOf(sql.NullTime{}):    "DATETIME(6)",
+}
+
+// SyncTable creates table from the fields of struct model, or adds columns
+// and indexes missing from an existing table; nothing is dropped or altered.
+// It returns the executed DDL, see SyncTableDDL for a dry run.
+// Besides the TagName options, db tags define the columns:
+//
+//	ID      uint64    `db:"id,pk,autoincr"`            // PRIMARY KEY, several pk fields make a composite key
+//	Email   string    `db:"email,unique,size=191"`     // VARCHAR(191) with unique key uniq_email
+//	OrgID   int64     `db:"org_id,index=org_name"`     // key org_name (org_id, name), shared index names group columns
+//	Name    string    `db:"name,index=org_name,charset=utf8mb4"`
+//	Bio     *string   `db:"bio,type=TEXT"`             // explicit type, pointers and sql.Null* are NULL
+//	Active  bool      `db:"active,default=1"`          // DEFAULT expression
+//	Created time.Time `db:"created,default=CURRENT_TIMESTAMP(6)"`
+//
+// Types follow Go kinds: integers map to TINYINT..BIGINT [UNSIGNED], bool to
+// TINYINT(1), floats to FLOAT or DOUBLE, string to VARCHAR(size, 255 by default),
+// []byte to VARBINARY(size) or BLOB, time.Time to DATETIME(6) and json fields to JSON.
+// Commas inside brackets and quotes do not split options:
+//
+//	Price   float64   `db:"price,type=DECIMAL(10,2)"`
+//	State   string    `db:"state,type=ENUM('new','done'),default='new'"`
+func (db *DB) SyncTable(table string, model interface{}) ([]string, error) {
+	ddl, err := db.SyncTableDDL(table, model)
+	if err != nil {
+		return nil, err
+	}
+	for i, statement := range ddl {
+		if _, err := db.exec(OpQuery, table, statement); err != nil {
+			return ddl[:i], err
+		}
+	}
+	return ddl, nil
+}
+
+// SyncTableDDL returns statements SyncTable would run without running them
+func (db *DB) SyncTableDDL(table string, model interface{}) ([]string, error) {
+	def, err := tableDefinition(model)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := db.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []string{def.createTable(db.qualified(table))}, nil
+	}
+
+	existing := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		existing[strings.ToLower(column.Name)] = true
+	}
+	indexes, err := db.Indexes(table)
+	if err != nil {
+		return nil, err
+	}
+	existingIndexes := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		existingIndexes[strings.ToLower(index.Name)] = true
+	}
+
+	var clauses []string
+	after := ""
+	for _, column := range def.columns {
+		if !existing[strings.ToLower(column.name)] {
+			clause := "ADD COLUMN " + column.definition()
+			if after == "" {
+				clause += " FIRST"
+			} else {
+				clause += " AFTER " + EscapeID(after, true)
+			}
+			clauses = append(clauses, clause)
+		}
+		after = column.name
+	}
+	for _, index := range def.indexes {
+		if !existingIndexes[strings.ToLower(index.name)] {
+			clauses = append(clauses, "ADD "+index.definition())
+		}
+	}
+	if len(clauses) == 0 {
+		return nil, nil
+	}
+	return []string{"ALTER TABLE " + db.qualified(table) + " " + strings.Join(clauses, ", ")}, nil
+}
+
+// tableDefinition reads table definition of struct or struct pointer model
+func tableDefinition(model interface{}) (*tableDef, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("mysql.synctable: model must be a struct or pointer to struct")
+	}
+
+	def := &tableDef{}
+	indexes := make(map[string]int) // name: position in def.indexes
+	for _, field := range structFields(t) {
+		column := columnDef{name: field.name}
+		var size string
+		for _, opt := range splitOptions(field.opts) {
+			key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			switch key {
+			case "pk":
+				def.primaryKey = append(def.primaryKey, field.name)
+			case "autoincr":
+				column.autoIncrement = true
+			case "unique", "index":
+				if value == "" {
+					value = map[string]string{"unique": "uniq_", "index": "idx_"}[key] + field.name
+				}
+				i, ok := indexes[strings.ToLower(value)]
+				if !ok {
+					i = len(def.indexes)
+					indexes[strings.ToLower(value)] = i
+					def.indexes = append(def.indexes, indexDef{name: value, unique: key == "unique"})
+				}
+				def.indexes[i].columns = append(def.indexes[i].columns, field.name)
+			case "size":
+				size = value
+			case "type":
+				column.sqlType = value
+			case "default":
+				column.def = value
+			case "charset":
+				column.charset = value
+			}
+		}
+
+		sqlType, nullable, err := columnType(t.FieldByIndex(field.index).Type, size, field.json)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.synctable: field %s: %v", field.name, err)
+		}
+		if column.sqlType == "" {
+			column.sqlType = sqlType
+		}
+		column.nullable = nullable
+		def.columns = append(def.columns, column)
+	}
+	if len(def.columns) == 0 {
+		return nil, errors.New("mysql.synctable: model has no columns")
+	}
+	return def, nil
+}
+
+// columnType returns column type of Go type t and whether it is nullable
+func columnType(t reflect.Type, size string, isJSON bool) (string, bool, error) {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	if isJSON || t == jsonValueType {
+		return "JSON", nullable, nil
+	}
+	if sqlType, ok := nullTypes[t]; ok {
+		if size != "" && t == reflect.TypeOf(sql.NullString{}) {
+			sqlType = "VARCHAR(" + size + ")"
+		}
+		return sqlType, true, nil
+	}
+	if t == timeType {
+		return "DATETIME(6)", nullable, nil
+	}
+	if t.ConvertibleTo(byteSliceType) && t.Kind() == reflect.Slice {
+		if size != "" {
+			return "VARBINARY(" + size + ")", nullable, nil
+		}
+		return "BLOB", nullable, nil
+	}
+
+	var sqlType string
+	switch t.Kind() {
+	case reflect.Bool:
+		return "TINYINT(1)", nullable, nil
+	case reflect.Int8, reflect.Uint8:
+		sqlType = "TINYINT"
+	case reflect.Int16, reflect.Uint16:
+		sqlType = "SMALLINT"
+	case reflect.Int32, reflect.Uint32:
+		sqlType = "INT"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		sqlType = "BIGINT"
+	case reflect.Float32:
+		return "FLOAT", nullable, nil
+	case reflect.Float64:
+		return "DOUBLE", nullable, nil
+	case reflect.String:
+		if size == "" {
+			size = "255"
+		}
+		return "VARCHAR(" + size + ")", nullable, nil
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BINARY(" + strconv.Itoa(t.Len()) + ")", nullable, nil
+		}
+		return "", false, errors.New("unsupported type " + t.String() + ", set type= or json")
+	default:
+		return "", false, errors.New("unsupported type " + t.String() + ", set type= or json")
+	}
+	if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr {
+		sqlType += " UNSIGNED"
+	}
+	return sqlType, nullable, nil
+}
+
+// definition returns column definition of CREATE and ALTER TABLE
+func (c *columnDef) definition() string {
+	var b strings.Builder
+	b.WriteString(EscapeID(c.name, true))
+	b.WriteByte(' ')
+	b.WriteString(c.sqlType)
+	if c.charset != "" {
+		b.WriteString(" CHARACTER SET ")
+		b.WriteString(c.charset)
+	}
+	if c.nullable {
+		b.WriteString(" NULL")
+	} else {
+		b.WriteString(" NOT NULL")
+	}
+	if c.def != "" {
+		b.WriteString(" DEFAULT ")
+		b.WriteString(c.def)
+	}
+	if c.autoIncrement {
+		b.WriteString(" AUTO_INCREMENT")
+	}
+	return b.String()
+}
+
+// definition returns index definition of CREATE and ALTER TABLE
+func (i *indexDef) definition() string {
+	kind := "KEY "
+	if i.unique {
+		kind = "UNIQUE KEY "
+	}
+	return kind + EscapeID(i.name, true) + " (" + EscapeIDs(i.columns, true) + ")"
+}
+
+// createTable returns CREATE TABLE statement of qualified table
+func (def *tableDef) createTable(table string) string {
+	var lines []string
+	for i := range def.columns {
+		lines = append(lines, def.columns[i].definition())
+	}
+	if len(def.primaryKey) > 0 {
+		lines = append(lines, "PRIMARY KEY ("+EscapeIDs(def.primaryKey, true)+")")
+	}
+	for i := range def.indexes {
+		lines = append(lines, def.indexes[i].definition())
+	}
+	return "CREATE TABLE IF NOT EXISTS " + table + " (\n  " + strings.Join(lines, ",\n  ") +
+		"\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+}
@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type syncBase struct {
+	ID      uint64    `db:"id,pk,autoincr,readonly"`
+	Created time.Time `db:"created,default=CURRENT_TIMESTAMP(6)"`
+}
+
+type syncUser struct {
+	syncBase
+	Email  string         `db:"email,unique,size=191"`
+	OrgID  int32          `db:"org_id,index=org_name"`
+	Name   string         `db:"name,index=org_name,charset=utf8mb4"`
+	Bio    *string        `db:"bio,type=TEXT"`
+	Active bool           `db:"active,default=1"`
+	Score  sql.NullInt64  `db:"score"`
+	Meta   map[string]int `db:"meta,json"`
+	Avatar []byte         `db:"avatar"`
+	Secret string         `db:"-"`
+}
+
+func TestSyncTableCreate(t *testing.T) {
+	db, _ := newTestDB(t,
+		result("information_schema.COLUMNS", []string{"name"}),
+	)
+	ddl, err := db.SyncTableDDL("users", &syncUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE TABLE IF NOT EXISTS `app`.`users` (\n" +
+		"  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,\n" +
+		"  `created` DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+		"  `email` VARCHAR(191) NOT NULL,\n" +
+		"  `org_id` INT NOT NULL,\n" +
+		"  `name` VARCHAR(255) CHARACTER SET utf8mb4 NOT NULL,\n" +
+		"  `bio` TEXT NULL,\n" +
+		"  `active` TINYINT(1) NOT NULL DEFAULT 1,\n" +
+		"  `score` BIGINT NULL,\n" +
+		"  `meta` JSON NOT NULL,\n" +
+		"  `avatar` BLOB NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uniq_email` (`email`),\n" +
+		"  KEY `org_name` (`org_id`, `name`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"}
+	if !reflect.DeepEqual(ddl, want) {
+		t.Errorf("SyncTableDDL() =\n%s\nwant\n%s", ddl, want)
+	}
+
+	if _, err := db.SyncTableDDL("users", struct{ C chan int }{}); err == nil {
+		t.Error("unsupported field type accepted")
+	}
+}
+
+func TestSyncTableAlter(t *testing.T) {
+	columns := result("information_schema.COLUMNS", []string{"name"})
+	for _, name := range []string{"id", "created", "EMAIL", "name", "bio", "active", "score", "meta", "avatar"} {
+		columns.Rows = append(columns.Rows, []driver.Value{name})
+	}
+	indexes := result("information_schema.STATISTICS", []string{"name", "column"}, []driver.Value{"PRIMARY", "id"}, []driver.Value{"uniq_email", "email"})
+	db, d := newTestDB(t, columns, indexes)
+	ddl, err := db.SyncTable("users", syncUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ALTER TABLE `app`.`users` ADD COLUMN `org_id` INT NOT NULL AFTER `email`, ADD KEY `org_name` (`org_id`, `name`)"}
+	if !reflect.DeepEqual(ddl, want) {
+		t.Errorf("SyncTable() = %q, want %q", ddl, want)
+	}
+	if got := d.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("executed %q, want %q", got, want)
+	}
+
+	columns.Rows = append(columns.Rows, []driver.Value{"org_id"})
+	indexes.Rows = append(indexes.Rows, []driver.Value{"org_name", "org_id"})
+	d.setResults(columns, indexes)
+	if ddl, err := db.SyncTableDDL("users", syncUser{}); err != nil || ddl != nil {
+		t.Errorf("SyncTableDDL() of synced table = %q, %v, want nothing", ddl, err)
+	}
+}
+
+func TestSyncTableTypeOptions(t *testing.T) {
+	db, _ := newTestDB(t,
+		result("information_schema.COLUMNS", []string{"name"}),
+	)
+	ddl, err := db.SyncTableDDL("orders", struct {
+		Price float64 `db:"price,type=DECIMAL(10,2),default=0"`
+		State string  `db:"state,type=ENUM('new','it\\'s, done'),default='new',index"`
+		Tags  string  `db:"tags,type=SET('a','b'),default='a,b'"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE TABLE IF NOT EXISTS `app`.`orders` (\n" +
+		"  `price` DECIMAL(10,2) NOT NULL DEFAULT 0,\n" +
+		"  `state` ENUM('new','it\\'s, done') NOT NULL DEFAULT 'new',\n" +
+		"  `tags` SET('a','b') NOT NULL DEFAULT 'a,b',\n" +
+		"  KEY `idx_state` (`state`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"}
+	if !reflect.DeepEqual(ddl, want) {
+		t.Errorf("SyncTableDDL() =\n%s\nwant\n%s", ddl, want)
+	}
+}
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vinhjaxt/mysql-go/internal/driverutil"
+)
+
+// testResult answers queries containing match, an empty match answers any query
+type testResult struct {
+	match string
+	driverutil.ResultSet
+}
+
+// result returns testResult of columns and rows for queries containing match
+func result(match string, columns []string, rows ...[]driver.Value) testResult {
+	return testResult{match, driverutil.ResultSet{Columns: columns, Rows: rows}}
+}
+
+// anyRows answers every query with rows 1 and 2 of column n
+var anyRows = result("", []string{"n"}, []driver.Value{int64(1)}, []driver.Value{int64(2)})
+
+// testDriver answers statements of package tests from memory, mysqltest.Fake
+// imports this package so it can not be used here. Queries get the first
+// result, in order, whose match they contain; execs, args and statement
+// counts are recorded
+type testDriver struct {
+	mu       sync.Mutex
+	results  []testResult
+	execs    []string
+	args     []driver.Value // of the last statement
+	failing  string         // execs containing failing fail
+	affected int64          // rows affected of execs
+	prepared int
+	closed   int
+}
+
+// newTestDB returns DB of schema app on a testDriver answering queries with results
+func newTestDB(t *testing.T, results ...testResult) (*DB, *testDriver) {
+	d := &testDriver{results: results}
+	db := &DB{Conn: sql.OpenDB(d), dbName: "app"}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func (d *testDriver) Connect(ctx context.Context) (driver.Conn, error) { return testConn{d}, nil }
+func (d *testDriver) Driver() driver.Driver                            { return d }
+func (d *testDriver) Open(name string) (driver.Conn, error)            { return testConn{d}, nil }
+
+// setResults replaces results answering later queries
+func (d *testDriver) setResults(results ...testResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.results = results
+}
+
+// setFailing makes execs containing failing fail
+func (d *testDriver) setFailing(failing string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failing = failing
+}
+
+// setAffected sets rows affected of execs
+func (d *testDriver) setAffected(affected int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.affected = affected
+}
+
+// executed returns and forgets statements executed so far
+func (d *testDriver) executed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	execs := d.execs
+	d.execs = nil
+	return execs
+}
+
+// lastArgs returns args of the last statement
+func (d *testDriver) lastArgs() []driver.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.args
+}
+
+// statements returns numbers of prepared and closed statements
+func (d *testDriver) statements() (prepared, closed int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.prepared, d.closed
+}
+
+type testConn struct{ d *testDriver }
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepared++
+	return testStmt{c.d, query}, nil
+}
+func (c testConn) Close() error                                { return nil }
+func (c testConn) Begin() (driver.Tx, error)                   { return testTx{}, nil }
+func (c testConn) CheckNamedValue(nv *driver.NamedValue) error { return driverutil.CheckNamedValue(nv) }
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testStmt struct {
+	d     *testDriver
+	query string
+}
+
+func (s testStmt) Close() error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.closed++
+	return nil
+}
+func (s testStmt) NumInput() int { return -1 }
+func (s testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.failing != "" && strings.Contains(s.query, s.d.failing) {
+		return nil, errors.New("syntax error")
+	}
+	s.d.execs = append(s.d.execs, s.query)
+	s.d.args = args
+	return driverutil.Result{Affected: s.d.affected}, nil
+}
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.args = args
+	for _, result := range s.d.results {
+		if strings.Contains(s.query, result.match) {
+			return driverutil.NewRows(result.ResultSet), nil
+		}
+	}
+	return nil, errors.New("unexpected query: " + s.query)
+}
@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+)
+
+// Tx transaction, its statement helpers run in the transaction
+type Tx struct {
+	db *DB // bound to the transaction
+	Tx *sql.Tx
+}
+
+// Begin starts a transaction
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(db.context(), nil)
+}
+
+// BeginTx starts a transaction, ctx is used until the transaction ends
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if db.tx != nil {
+		return nil, errors.New("mysql: nested transactions are not supported")
+	}
+	txDB := *db
+	txDB.ctx = ctx
+	c := txDB.before(OpBegin, "", "START TRANSACTION", nil)
+	var tx *sql.Tx
+	var err error
+	if db.conn != nil {
+		tx, err = db.conn.BeginTx(c.ctx, opts)
+	} else {
+		tx, err = db.Conn.BeginTx(c.ctx, opts)
+	}
+	c.after(nil, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	txDB.tx = tx
+	return &Tx{
+		db: &txDB,
+		Tx: tx,
+	}, nil
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit() error {
+	c := tx.db.before(OpCommit, "", "COMMIT", nil)
+	err := tx.Tx.Commit()
+	c.after(nil, 0, err)
+	return err
+}
+
+// Rollback aborts the transaction
+func (tx *Tx) Rollback() error {
+	c := tx.db.before(OpRollback, "", "ROLLBACK", nil)
+	err := tx.Tx.Rollback()
+	c.after(nil, 0, err)
+	return err
+}
+
+// Single see DB.Single
+func (tx *Tx) Single(sqlQuery string, values ...interface{}) (*sql.NullString, error) {
+	return tx.db.Single(sqlQuery, values...)
+}
+
+// Row see DB.Row
+func (tx *Tx) Row(sqlQuery string, args ...interface{}) (map[string]*sql.NullString, error) {
+	return tx.db.Row(sqlQuery, args...)
+}
+
+// Rows see DB.Rows
+func (tx *Tx) Rows(sqlQuery string, args ...interface{}) ([]map[string]*sql.NullString, error) {
+	return tx.db.Rows(sqlQuery, args...)
+}
+
+// SetRows see DB.SetRows
+func (tx *Tx) SetRows(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error) {
+	return tx.db.SetRows(sqlQuery, args...)
+}
+
+// SetRowsNil see DB.SetRowsNil
+func (tx *Tx) SetRowsNil(sqlQuery string, args ...interface{}) ([][]map[string]*sql.NullString, error) {
+	return tx.db.SetRowsNil(sqlQuery, args...)
+}
+
+// ScanRow see DB.ScanRow
+func (tx *Tx) ScanRow(dest interface{}, sqlQuery string, args ...interface{}) error {
+	return tx.db.ScanRow(dest, sqlQuery, args...)
+}
+
+// ScanRows see DB.ScanRows
+func (tx *Tx) ScanRows(dest interface{}, sqlQuery string, args ...interface{}) error {
+	return tx.db.ScanRows(dest, sqlQuery, args...)
+}
+
+// Insert see DB.Insert
+func (tx *Tx) Insert(table string, columns []string, data []interface{}) (int64, error) {
+	return tx.db.Insert(table, columns, data)
+}
+
+// InsertUpdate see DB.InsertUpdate
+func (tx *Tx) InsertUpdate(table string, columns []string, data []interface{}) (sql.Result, error) {
+	return tx.db.InsertUpdate(table, columns, data)
+}
+
+// Update see DB.Update
+func (tx *Tx) Update(table string, data interface{}, where interface{}, limits ...uint64) (int64, error) {
+	return tx.db.Update(table, data, where, limits...)
+}
+
+// Delete see DB.Delete
+func (tx *Tx) Delete(table string, where interface{}, limits ...uint64) (int64, error) {
+	return tx.db.Delete(table, where, limits...)
+}
+
+// Query see DB.Query
+func (tx *Tx) Query(sql string, values ...interface{}) (sql.Result, error) {
+	return tx.db.Query(sql, values...)
+}
+
+// Escape see DB.Escape
+func (tx *Tx) Escape(val interface{}, stringifyObjects bool) (string, error) {
+	return tx.db.Escape(val, stringifyObjects)
+}
+
+// EscapeID see DB.EscapeID
+func (tx *Tx) EscapeID(val string, forbidQualified bool) string {
+	return tx.db.EscapeID(val, forbidQualified)
+}
+
+// ExecScript see DB.ExecScript, statements run in the transaction
+func (tx *Tx) ExecScript(ctx context.Context, r io.Reader, opts ...ScriptOption) error {
+	return tx.db.ExecScript(ctx, r, opts...)
+}